@@ -0,0 +1,53 @@
+package prompt
+
+import "strings"
+
+// AutoSuggester suggests the text following the input, which is displayed
+// after the cursor like fish shell and accepted by Right, End, Ctrl+F or Alt+F.
+type AutoSuggester interface {
+	// AutoSuggest returns the text to be appended to the input, or "" to suggest nothing.
+	// It is called only when the cursor is at the end of the non-empty input.
+	AutoSuggest(d Document) string
+}
+
+// AutoSuggest returns the rest of the newest entry which starts with the input.
+func (h *History) AutoSuggest(d Document) string {
+	for i := len(h.histories) - 1; i >= 0; i-- {
+		if e := h.histories[i]; len(e) > len(d.Text) && strings.HasPrefix(e, d.Text) {
+			return e[len(d.Text):]
+		}
+	}
+	return ""
+}
+
+// updateAutoSuggestion asks the AutoSuggester for the text following the input.
+func (p *Prompt) updateAutoSuggestion() {
+	s := ""
+	d := p.buf.Document()
+	if p.autoSuggester != nil && !p.search.active && d.Text != "" && d.TextAfterCursor() == "" {
+		if _, ok := p.completion.GetSelectedSuggestion(); !ok {
+			s = p.autoSuggester.AutoSuggest(*d)
+		}
+	}
+	p.renderer.autoSuggestion = s
+}
+
+// acceptAutoSuggestion inserts the suggested text when ev is a key to accept it.
+// Alt+F accepts only the first word of it.
+func (p *Prompt) acceptAutoSuggestion(ev KeyEvent) bool {
+	s := p.renderer.autoSuggestion
+	if s == "" || p.buf.Document().TextAfterCursor() != "" {
+		return false
+	}
+	switch {
+	case ev.Modifiers == 0 && (ev.Key == Right || ev.Key == End || ev.Key == ControlF):
+	case ev.Rune == 'f' && ev.Modifiers == ModAlt:
+		d := &Document{Text: s}
+		s = s[:d.FindEndOfCurrentWordWithSpace()]
+	default:
+		return false
+	}
+	p.buf.InsertText(s, false, true)
+	p.updateAutoSuggestion()
+	return true
+}
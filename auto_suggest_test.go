@@ -0,0 +1,108 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHistoryAutoSuggest(t *testing.T) {
+	h := NewHistory()
+	h.Add("git status")
+	h.Add("git commit")
+	h.Add("ls")
+
+	scenarioTable := []struct {
+		text     string
+		expected string
+	}{
+		{text: "git", expected: " commit"},
+		{text: "git s", expected: "tatus"},
+		{text: "git commit", expected: ""},
+		{text: "cd", expected: ""},
+	}
+	for _, s := range scenarioTable {
+		if actual := h.AutoSuggest(Document{Text: s.text, cursorPosition: len(s.text)}); actual != s.expected {
+			t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+		}
+	}
+}
+
+func TestPromptAutoSuggestion(t *testing.T) {
+	scenarioTable := []struct {
+		name         string
+		keys         []string
+		expectedText string
+		expected     string
+	}{
+		{
+			name:         "suggest from history",
+			keys:         []string{"g", "i"},
+			expectedText: "gi",
+			expected:     "t push origin",
+		},
+		{
+			name:         "accept with right",
+			keys:         []string{"g", "\x1b[C"},
+			expectedText: "git push origin",
+			expected:     "",
+		},
+		{
+			name:         "accept with ctrl-f",
+			keys:         []string{"g", "\x06"},
+			expectedText: "git push origin",
+			expected:     "",
+		},
+		{
+			name:         "accept a word with alt-f",
+			keys:         []string{"g", "i", "t", "\x1bf"},
+			expectedText: "git push",
+			expected:     " origin",
+		},
+		{
+			name:         "no suggestion unless cursor is at the end",
+			keys:         []string{"g", "i", "\x1b[D"},
+			expectedText: "gi",
+			expected:     "",
+		},
+		{
+			name:         "no suggestion for empty input",
+			keys:         []string{"g", "\x7f"},
+			expectedText: "",
+			expected:     "",
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newMockPrompt(nil, newMockParser(), OptionHistory([]string{"git push origin"}), OptionHistoryAutoSuggestion())
+			for _, k := range s.keys {
+				_, _ = p.feed(NewKeyEvent([]byte(k)))
+				p.updateAutoSuggestion()
+			}
+			if actual := p.buf.Text(); actual != s.expectedText {
+				t.Errorf("Should be %#v, but got %#v", s.expectedText, actual)
+			}
+			if actual := p.renderer.autoSuggestion; actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPromptAcceptAutoSuggestionFinishesKey(t *testing.T) {
+	p := newMockPrompt(nil, newMockParser(), OptionHistory([]string{"git push origin"}), OptionHistoryAutoSuggestion())
+	for _, k := range []string{"g", "i", "t", " ", "p", "\x17", "\x1b[C", "\x17"} {
+		_, _ = p.feed(NewKeyEvent([]byte(k)))
+		p.updateAutoSuggestion()
+	}
+	// The kill after accepting the suggestion doesn't continue the previous one.
+	expected := []string{"p", "origin"}
+	if !reflect.DeepEqual(p.killRing.entries, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, p.killRing.entries)
+	}
+
+	_, _ = p.feed(NewKeyEvent([]byte("\x1b[C")))
+	if p.buf.lastKeyStroke != Right {
+		t.Errorf("Should be %#v, but got %#v", Right, p.buf.lastKeyStroke)
+	}
+}
@@ -14,6 +14,21 @@ type Buffer struct {
 	cacheDocument   *Document
 	preferredColumn int // Remember the original column for the next up/down movement.
 	lastKeyStroke   Key
+	selectionStart  int // The other end of the selection from the cursor. -1 means nothing is selected.
+	killRing        *KillRing
+
+	// Undo history. Each state is the text and the cursor position before an edit.
+	undoStack  []bufferState
+	redoStack  []bufferState
+	typing     bool // The last undo step is a run of typed characters ending at typingEnd.
+	typingEnd  int
+	groupDepth int // Edits between beginEditGroup and endEditGroup make a single undo step.
+	groupSaved bool
+}
+
+type bufferState struct {
+	text           string
+	cursorPosition int
 }
 
 // Text returns string of the current line.
@@ -43,6 +58,20 @@ func (b *Buffer) DisplayCursorPosition() int {
 
 // InsertText insert string from current line.
 func (b *Buffer) InsertText(v string, overwrite bool, moveCursor bool) {
+	if v != "" {
+		b.saveUndoState(false)
+	}
+	b.insertText(v, overwrite, moveCursor)
+}
+
+// insertTyped inserts a typed character. Consecutive typed characters are undone at once.
+func (b *Buffer) insertTyped(v string) {
+	b.saveUndoState(true)
+	b.insertText(v, false, true)
+	b.typingEnd = b.cursorPosition
+}
+
+func (b *Buffer) insertText(v string, overwrite bool, moveCursor bool) {
 	or := []rune(b.Text())
 	oc := b.cursorPosition
 
@@ -128,7 +157,8 @@ func (b *Buffer) DeleteBeforeCursor(count int) (deleted string) {
 	debug.Assert(count >= 0, "count should be positive")
 	r := []rune(b.Text())
 
-	if b.cursorPosition > 0 {
+	if b.cursorPosition > 0 && count > 0 {
+		b.saveUndoState(false)
 		start := b.cursorPosition - count
 		if start < 0 {
 			start = 0
@@ -142,6 +172,21 @@ func (b *Buffer) DeleteBeforeCursor(count int) (deleted string) {
 	return
 }
 
+// selection returns the selected range [from, to) which includes the character under the cursor.
+func (b *Buffer) selection() (from, to int, ok bool) {
+	if b.selectionStart == -1 {
+		return 0, 0, false
+	}
+	from, to = b.selectionStart, b.cursorPosition
+	if from > to {
+		from, to = to, from
+	}
+	if n := len([]rune(b.Text())); to < n {
+		to++
+	}
+	return from, to, true
+}
+
 // NewLine means CR.
 func (b *Buffer) NewLine(copyMargin bool) {
 	if copyMargin {
@@ -154,16 +199,43 @@ func (b *Buffer) NewLine(copyMargin bool) {
 // Delete specified number of characters and Return the deleted text.
 func (b *Buffer) Delete(count int) (deleted string) {
 	r := []rune(b.Text())
-	if b.cursorPosition < len(r) {
-		deleted = b.Document().TextAfterCursor()[:count]
-		b.setText(string(r[:b.cursorPosition]) + string(r[b.cursorPosition+len(deleted):]))
+	if b.cursorPosition < len(r) && count > 0 {
+		b.saveUndoState(false)
+		end := b.cursorPosition + count
+		if end > len(r) {
+			end = len(r)
+		}
+		deleted = string(r[b.cursorPosition:end])
+		b.setText(string(r[:b.cursorPosition]) + string(r[end:]))
 	}
 	return
 }
 
+// KillRing returns the kill ring which stores the texts cut by key bindings.
+// The buffers of a Prompt share the same kill ring.
+func (b *Buffer) KillRing() *KillRing {
+	if b.killRing == nil {
+		b.killRing = NewKillRing()
+	}
+	return b.killRing
+}
+
+// Yank inserts the newest entry of the kill ring at the cursor.
+func (b *Buffer) Yank() {
+	b.KillRing().yank(b)
+}
+
+// YankPop replaces the text inserted by the previous Yank or YankPop with the next older entry of the kill ring.
+// It does nothing unless the previous key yanked.
+func (b *Buffer) YankPop() {
+	b.KillRing().yankPop(b)
+}
+
 // JoinNextLine joins the next line to the current one by deleting the line ending after the current line.
 func (b *Buffer) JoinNextLine(separator string) {
 	if !b.Document().OnLastLine() {
+		b.beginEditGroup()
+		defer b.endEditGroup()
 		b.cursorPosition += b.Document().GetEndOfLinePosition()
 		b.Delete(1)
 		// Remove spaces
@@ -174,18 +246,84 @@ func (b *Buffer) JoinNextLine(separator string) {
 // SwapCharactersBeforeCursor swaps the last two characters before the cursor.
 func (b *Buffer) SwapCharactersBeforeCursor() {
 	if b.cursorPosition >= 2 {
+		b.saveUndoState(false)
 		x := b.Text()[b.cursorPosition-2 : b.cursorPosition-1]
 		y := b.Text()[b.cursorPosition-1 : b.cursorPosition]
 		b.setText(b.Text()[:b.cursorPosition-2] + y + x + b.Text()[b.cursorPosition:])
 	}
 }
 
+// Undo reverts the last edit. It returns false if there is nothing to undo.
+func (b *Buffer) Undo() bool {
+	if len(b.undoStack) == 0 {
+		return false
+	}
+	b.redoStack = append(b.redoStack, b.state())
+	b.restoreState(b.undoStack[len(b.undoStack)-1])
+	b.undoStack = b.undoStack[:len(b.undoStack)-1]
+	return true
+}
+
+// Redo reapplies the last edit reverted by Undo. It returns false if there is nothing to redo.
+func (b *Buffer) Redo() bool {
+	if len(b.redoStack) == 0 {
+		return false
+	}
+	b.undoStack = append(b.undoStack, b.state())
+	b.restoreState(b.redoStack[len(b.redoStack)-1])
+	b.redoStack = b.redoStack[:len(b.redoStack)-1]
+	return true
+}
+
+func (b *Buffer) state() bufferState {
+	return bufferState{text: b.Text(), cursorPosition: b.cursorPosition}
+}
+
+func (b *Buffer) restoreState(s bufferState) {
+	b.setDocument(&Document{Text: s.text, cursorPosition: s.cursorPosition})
+	b.typing = false
+}
+
+// saveUndoState records the state before an edit as an undo step.
+// typing tells that the edit inserts a typed character, which continues the previous run of typing.
+func (b *Buffer) saveUndoState(typing bool) {
+	if b.groupDepth > 0 {
+		if b.groupSaved {
+			return
+		}
+		b.groupSaved = true
+		typing = false
+	} else if typing && b.typing && b.cursorPosition == b.typingEnd {
+		return
+	}
+	b.undoStack = append(b.undoStack, b.state())
+	b.redoStack = nil
+	b.typing = typing
+}
+
+// beginEditGroup starts recording the following edits as a single undo step until endEditGroup.
+// The calls can be nested.
+func (b *Buffer) beginEditGroup() {
+	if b.groupDepth == 0 {
+		b.groupSaved = false
+	}
+	b.groupDepth++
+}
+
+// endEditGroup ends the undo step started by beginEditGroup.
+func (b *Buffer) endEditGroup() {
+	if b.groupDepth > 0 {
+		b.groupDepth--
+	}
+}
+
 // NewBuffer is constructor of Buffer struct.
 func NewBuffer() (b *Buffer) {
 	b = &Buffer{
 		workingLines:    []string{""},
 		workingIndex:    0,
 		preferredColumn: -1, // -1 means nil
+		selectionStart:  -1, // -1 means nil
 	}
 	return
 }
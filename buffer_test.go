@@ -196,3 +196,68 @@ func TestBuffer_SwapCharactersBeforeCursor(t *testing.T) {
 		t.Errorf("Should be %#v, got %#v", ex, ac)
 	}
 }
+
+func TestBuffer_Delete(t *testing.T) {
+	b := NewBuffer()
+	b.InsertText("日本語です", false, false)
+	b.CursorRight(1)
+
+	if deleted := b.Delete(2); deleted != "本語" {
+		t.Errorf("Should be %#v, but got %#v", "本語", deleted)
+	}
+	if b.Text() != "日です" {
+		t.Errorf("Should be %#v, but got %#v", "日です", b.Text())
+	}
+	if deleted := b.Delete(10); deleted != "です" {
+		t.Errorf("Should be %#v, but got %#v", "です", deleted)
+	}
+}
+
+func TestBuffer_UndoRedo(t *testing.T) {
+	b := NewBuffer()
+	for _, r := range "foo bar" {
+		b.insertTyped(string(r))
+	}
+	b.DeleteBeforeCursor(3)
+	b.InsertText("baz", false, true)
+	b.CursorLeft(4)
+	b.insertTyped("!")
+
+	for _, expected := range []string{"foo baz", "foo ", "foo bar", ""} {
+		if !b.Undo() {
+			t.Fatal("Should undo")
+		}
+		if b.Text() != expected {
+			t.Errorf("Should be %#v, but got %#v", expected, b.Text())
+		}
+	}
+	if b.Undo() {
+		t.Error("Should not undo any more")
+	}
+
+	b.Redo()
+	if b.Text() != "foo bar" || b.cursorPosition != 7 {
+		t.Errorf("Should be %#v, but got %#v (cursor %d)", "foo bar", b.Text(), b.cursorPosition)
+	}
+
+	// A new edit clears the redo history.
+	b.InsertText("?", false, true)
+	if b.Redo() {
+		t.Error("Should not redo after a new edit")
+	}
+}
+
+func TestBuffer_EditGroup(t *testing.T) {
+	b := NewBuffer()
+	b.InsertText("foo ba", false, true)
+
+	b.beginEditGroup()
+	b.DeleteBeforeCursor(2)
+	b.InsertText("bar", false, true)
+	b.endEditGroup()
+
+	b.Undo()
+	if b.Text() != "foo ba" {
+		t.Errorf("Should be %#v, but got %#v", "foo ba", b.Text())
+	}
+}
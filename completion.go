@@ -2,6 +2,7 @@ package prompt
 
 import (
 	"strings"
+	"unicode/utf8"
 
 	"github.com/c-bata/go-prompt/internal/debug"
 	runewidth "github.com/mattn/go-runewidth"
@@ -23,8 +24,46 @@ var (
 
 // Suggest is printed when completing.
 type Suggest struct {
+	// Text is inserted into the input when the suggestion is accepted.
 	Text        string
 	Description string
+	// Display is displayed in the completion menu instead of Text if it's not empty.
+	Display string
+	// Replace is the range of the input replaced by Text.
+	// When it's nil, the word before the cursor until the word separator is replaced.
+	Replace *ReplaceRange
+}
+
+// ReplaceRange is a range of the input text. Start and End are offsets in runes relative to the cursor,
+// so that ReplaceRange{Start: -2, End: 1} replaces two characters before the cursor and one after it.
+type ReplaceRange struct {
+	Start int
+	End   int
+}
+
+func (s *Suggest) displayText() string {
+	if s.Display != "" {
+		return s.Display
+	}
+	return s.Text
+}
+
+// replacedRange returns the positions in runes of the text in d replaced by s.
+func (s *Suggest) replacedRange(d *Document, wordSeparator string) (start, end int) {
+	cursor := d.cursorPosition
+	if s.Replace == nil {
+		return cursor - utf8.RuneCountInString(d.GetWordBeforeCursorUntilSeparator(wordSeparator)), cursor
+	}
+	start = maxInt(cursor+minInt(s.Replace.Start, 0), 0)
+	end = minInt(cursor+maxInt(s.Replace.End, 0), utf8.RuneCountInString(d.Text))
+	return start, end
+}
+
+// apply returns the text and the cursor position after replacing the range of d by s.
+func (s *Suggest) apply(d *Document, wordSeparator string) (text string, cursor int) {
+	start, end := s.replacedRange(d, wordSeparator)
+	rs := []rune(d.Text)
+	return string(rs[:start]) + s.Text + string(rs[end:]), start + utf8.RuneCountInString(s.Text)
 }
 
 // CompletionManager manages which suggestion is now selected.
@@ -37,6 +76,8 @@ type CompletionManager struct {
 	verticalScroll int
 	wordSeparator  string
 	showAtStart    bool
+	layout         CompletionLayout
+	async          *asyncCompletion
 }
 
 // GetSelectedSuggestion returns the selected item.
@@ -60,14 +101,29 @@ func (c *CompletionManager) GetSuggestions() []Suggest {
 func (c *CompletionManager) Reset() {
 	c.selected = -1
 	c.verticalScroll = 0
-	c.Update(*NewDocument())
+	if c.async == nil {
+		c.Update(*NewDocument())
+	}
 }
 
 // Update to update the suggestions.
+// With AsyncCompleter, it starts updating them unless in is the same as the last one.
 func (c *CompletionManager) Update(in Document) {
+	if c.async != nil {
+		c.updateAsync(in)
+		return
+	}
 	c.tmp = c.completer(in)
 }
 
+// refresh updates the suggestions even if in is the same as the last one.
+func (c *CompletionManager) refresh(in Document) {
+	if c.async != nil {
+		c.stopAsync()
+	}
+	c.Update(in)
+}
+
 // Previous to select the previous suggestion item.
 func (c *CompletionManager) Previous() {
 	if c.verticalScroll == c.selected && c.selected > 0 {
@@ -159,7 +215,7 @@ func formatSuggestions(suggests []Suggest, max int) (new []Suggest, width int) {
 
 	left := make([]string, num)
 	for i := 0; i < num; i++ {
-		left[i] = suggests[i].Text
+		left[i] = suggests[i].displayText()
 	}
 	right := make([]string, num)
 	for i := 0; i < num; i++ {
@@ -178,6 +234,45 @@ func formatSuggestions(suggests []Suggest, max int) (new []Suggest, width int) {
 	return new, leftWidth + rightWidth
 }
 
+// formatMatches shifts matches in the displayed text of s to the positions in the text formatted in width,
+// dropping the runes cut off by shortenSuffix.
+func formatMatches(s Suggest, matches []int, width int) []int {
+	if len(matches) == 0 {
+		return nil
+	}
+	text := deleteBreakLineCharacters(s.displayText())
+	if len([]rune(text)) != len([]rune(s.displayText())) {
+		return nil
+	}
+	visible := len([]rune(text))
+	if w := width - runewidth.StringWidth(leftPrefix) - runewidth.StringWidth(leftSuffix); runewidth.StringWidth(text) > w {
+		visible = len([]rune(runewidth.Truncate(text, w, shortenSuffix))) - len([]rune(shortenSuffix))
+	}
+	offset := len([]rune(leftPrefix))
+	ret := make([]int, 0, len(matches))
+	for _, i := range matches {
+		if i < visible {
+			ret = append(ret, i+offset)
+		}
+	}
+	return ret
+}
+
+// matchesOf returns the positions in runes of the characters in the displayed text of s
+// which fuzzy match the text before the cursor replaced by s.
+func (c *CompletionManager) matchesOf(d *Document, s Suggest) []int {
+	start, _ := s.replacedRange(d, c.wordSeparator)
+	rs := []rune(d.Text)
+	if start >= d.cursorPosition || d.cursorPosition > len(rs) {
+		return nil
+	}
+	_, matches, ok := FuzzyMatch(s.displayText(), string(rs[start:d.cursorPosition]), true)
+	if !ok {
+		return nil
+	}
+	return matches
+}
+
 // NewCompletionManager returns initialized CompletionManager object.
 func NewCompletionManager(completer Completer, max uint16) *CompletionManager {
 	return &CompletionManager{
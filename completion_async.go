@@ -0,0 +1,99 @@
+package prompt
+
+import (
+	"context"
+	"time"
+)
+
+// AsyncCompleter returns the suggestions for Document like Completer, but it's called in another goroutine
+// so that a slow completer doesn't block typing. ctx is canceled when the Document changes.
+type AsyncCompleter func(ctx context.Context, d Document) []Suggest
+
+// loadingSuggestion is displayed in the completion menu while AsyncCompleter is running slowly.
+var loadingSuggestion = Suggest{Text: "Loading..."}
+
+// asyncLoadingDelay is how long AsyncCompleter runs before "Loading..." is displayed,
+// so that it doesn't flicker for a fast completer.
+const asyncLoadingDelay = 200 * time.Millisecond
+
+// asyncCompletion holds the state of AsyncCompleter in CompletionManager.
+type asyncCompletion struct {
+	completer    AsyncCompleter
+	delay        time.Duration
+	loadingDelay time.Duration
+	// post sends fn to the main loop of the prompt.
+	post func(fn func())
+
+	loading   bool
+	requested *Document
+	cancel    context.CancelFunc
+	seq       uint64
+}
+
+// updateAsync starts AsyncCompleter for in after the delay unless in is already requested.
+// The suggestions are replaced when it returns, unless another Document is requested in the meantime.
+func (c *CompletionManager) updateAsync(in Document) {
+	a := c.async
+	if r := a.requested; r != nil && r.Text == in.Text && r.cursorPosition == in.cursorPosition {
+		return
+	}
+	c.stopAsync()
+	a.requested = &in
+	c.tmp = nil
+	c.selected = -1
+	c.verticalScroll = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	seq, completer, delay, loadingDelay, post := a.seq, a.completer, a.delay, a.loadingDelay, a.post
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+		loading := time.AfterFunc(loadingDelay, func() {
+			post(func() {
+				// The suggestions may arrive first.
+				if seq == a.seq && ctx.Err() == nil {
+					a.loading = true
+				}
+			})
+		})
+		suggestions := completer(ctx, in)
+		loading.Stop()
+		if ctx.Err() != nil {
+			return
+		}
+		post(func() {
+			if seq != a.seq {
+				// Stale suggestions for an older Document.
+				return
+			}
+			a.loading = false
+			a.cancel = nil
+			cancel()
+			c.tmp = suggestions
+		})
+	}()
+}
+
+// stopAsync cancels the running AsyncCompleter.
+func (c *CompletionManager) stopAsync() {
+	a := c.async
+	if a.cancel != nil {
+		a.cancel()
+		a.cancel = nil
+	}
+	a.requested = nil
+	a.loading = false
+	// Discard the suggestions already sent to the main loop.
+	a.seq++
+}
+
+// Loading returns whether AsyncCompleter has been running for asyncLoadingDelay.
+func (c *CompletionManager) Loading() bool {
+	return c.async != nil && c.async.loading
+}
@@ -0,0 +1,138 @@
+package prompt
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsyncCompleter(t *testing.T) {
+	started, canceled := make(chan struct{}), make(chan string, 1)
+	completer := func(ctx context.Context, d Document) []Suggest {
+		if d.Text == "slow" {
+			close(started)
+			<-ctx.Done()
+			canceled <- d.Text
+		}
+		return []Suggest{{Text: d.Text + "1"}}
+	}
+	p := newMockPrompt(nil, newMockParser(), OptionAsyncCompleter(completer, 0))
+	c := p.completion
+	c.async.loadingDelay = time.Hour
+	nextEvent := func() event {
+		select {
+		case <-p.events.ready:
+			if ev, ok := p.events.pop(); ok {
+				return ev
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Should send the suggestions to the main loop")
+		}
+		return event{}
+	}
+
+	// The running completer is canceled when the Document changes.
+	c.Update(Document{Text: "slow", cursorPosition: 4})
+	if c.Loading() {
+		t.Errorf("Should be %#v, but got %#v", false, c.Loading())
+	}
+	<-started
+	c.Update(Document{Text: "a", cursorPosition: 1})
+	if actual := <-canceled; actual != "slow" {
+		t.Errorf("Should be %#v, but got %#v", "slow", actual)
+	}
+	stale := nextEvent()
+
+	// The suggestions for the older Document are discarded.
+	c.Update(Document{Text: "ab", cursorPosition: 2})
+	stale.fn()
+	if c.GetSuggestions() != nil {
+		t.Errorf("Should be %#v, but got %#v", nil, c.GetSuggestions())
+	}
+	// The same Document isn't requested again.
+	c.Update(Document{Text: "ab", cursorPosition: 2})
+	nextEvent().fn()
+	expected := []Suggest{{Text: "ab1"}}
+	if !reflect.DeepEqual(c.GetSuggestions(), expected) || c.Loading() {
+		t.Errorf("Should be %#v, but got %#v", expected, c.GetSuggestions())
+	}
+	time.Sleep(10 * time.Millisecond)
+	if ev, ok := p.events.pop(); ok {
+		t.Errorf("Should be nothing, but got %#v", ev)
+	}
+}
+
+func TestAsyncCompleterDebounce(t *testing.T) {
+	called := make(chan string, 3)
+	completer := func(ctx context.Context, d Document) []Suggest {
+		called <- d.Text
+		return nil
+	}
+	p := newMockPrompt(nil, newMockParser(), OptionAsyncCompleter(completer, 20*time.Millisecond))
+	for _, text := range []string{"a", "ab", "abc"} {
+		p.completion.Update(Document{Text: text, cursorPosition: len(text)})
+	}
+	if actual := <-called; actual != "abc" {
+		t.Errorf("Should be %#v, but got %#v", "abc", actual)
+	}
+	select {
+	case actual := <-called:
+		t.Errorf("Should be called once, but got %#v", actual)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRenderLoadingSuggestion(t *testing.T) {
+	w := &recordingWriter{}
+	p := newMockPrompt(nil, newMockParser(), OptionWriter(w), OptionAsyncCompleter(func(ctx context.Context, d Document) []Suggest {
+		<-ctx.Done()
+		return nil
+	}, 0))
+	p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 80})
+	p.completion.async.loadingDelay = 20 * time.Millisecond
+	p.completion.Update(*p.buf.Document())
+	defer p.completion.stopAsync()
+
+	// "Loading..." isn't displayed until the completer takes a while.
+	p.renderer.Render(p.buf, p.completion)
+	if strings.Contains(string(w.flushed), loadingSuggestion.Text) {
+		t.Errorf("Should not contain %#v, but got %#v", loadingSuggestion.Text, string(w.flushed))
+	}
+	select {
+	case <-p.events.ready:
+		if ev, ok := p.events.pop(); ok {
+			ev.fn()
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Should send the loading state to the main loop")
+	}
+	p.renderer.Render(p.buf, p.completion)
+	if !strings.Contains(string(w.flushed), loadingSuggestion.Text) {
+		t.Errorf("Should contain %#v, but got %#v", loadingSuggestion.Text, string(w.flushed))
+	}
+}
+
+func TestAsyncCompleterCanceledBySearch(t *testing.T) {
+	started, canceled := make(chan struct{}), make(chan struct{})
+	p := newMockPrompt(nil, newMockParser(), OptionAsyncCompleter(func(ctx context.Context, d Document) []Suggest {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return nil
+	}, 0))
+	p.completion.Update(*p.buf.Document())
+	<-started
+	if _, _, err := p.feedKeys([]KeyEvent{NewKeyEvent([]byte{0x12})}, false); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("Should cancel the completer when the search starts")
+	}
+	if p.completion.Loading() {
+		t.Errorf("Should be %#v, but got %#v", false, p.completion.Loading())
+	}
+}
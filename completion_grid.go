@@ -0,0 +1,199 @@
+package prompt
+
+import (
+	"strings"
+
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+// CompletionLayout is the layout of the completion menu.
+type CompletionLayout int
+
+const (
+	// CompletionColumn displays the suggestions and their descriptions in a column next to the cursor.
+	CompletionColumn CompletionLayout = iota
+	// CompletionGrid packs the suggestions into as many columns as fit the window like zsh,
+	// and displays the description of the selected one below them.
+	CompletionGrid
+)
+
+// formatGrid formats the displayed texts of the suggestions into cells of the same width
+// which fit in width, and returns them with the number of columns.
+func formatGrid(suggests []Suggest, width int) (cells []Suggest, columns int) {
+	texts := make([]Suggest, len(suggests))
+	for i := range suggests {
+		texts[i] = Suggest{Text: suggests[i].displayText()}
+	}
+	cells, cellWidth := formatSuggestions(texts, width)
+	if cellWidth == 0 {
+		return cells, 0
+	}
+	return cells, maxInt(width/cellWidth, 1)
+}
+
+// gridColumns returns the number of columns of the grid completion menu in the window.
+func (r *Render) gridColumns(suggests []Suggest) int {
+	// The last column is left blank so that the rows don't wrap.
+	_, columns := formatGrid(suggests, int(r.col)-1)
+	return maxInt(columns, 1)
+}
+
+// selectGrid selects the i-th suggestion in the grid of columns,
+// scrolling it so that the row of the selected one is displayed.
+func (c *CompletionManager) selectGrid(i, columns int) {
+	c.selected = i
+	if i < 0 {
+		c.verticalScroll = 0
+		return
+	}
+	if row := i / columns; row < c.verticalScroll {
+		c.verticalScroll = row
+	} else if row >= c.verticalScroll+int(c.max) {
+		c.verticalScroll = row - int(c.max) + 1
+	}
+}
+
+// handleGridKeyBinding moves the selection in the grid completion menu.
+// Tab and Shift+Tab select the next and the previous one, and the arrow keys move
+// in the grid while completing. handled reports whether the selection is moved,
+// and consumed reports whether the key shouldn't be handled by the other key bindings.
+func (p *Prompt) handleGridKeyBinding(key Key, completing bool) (handled, consumed bool) {
+	c := p.completion
+	n := len(c.tmp)
+	if c.layout != CompletionGrid || n == 0 {
+		return false, false
+	}
+	columns := p.renderer.gridColumns(c.tmp)
+	i := c.selected
+	switch key {
+	case Tab, ControlI:
+		if i++; i >= n {
+			i = -1
+		}
+		c.selectGrid(i, columns)
+		return true, false
+	case BackTab:
+		if i--; i < -1 {
+			i = n - 1
+		}
+		c.selectGrid(i, columns)
+		return true, false
+	case Down:
+		if !completing {
+			if !p.completionOnDown {
+				return false, false
+			}
+			c.selectGrid(0, columns)
+			return true, true
+		}
+		switch {
+		case i+columns < n:
+			i += columns
+		case i/columns == (n-1)/columns:
+			// Go round to the first row.
+			i %= columns
+		default:
+			// The row below is too short to have the column.
+			i = n - 1
+		}
+	case Up:
+		if !completing {
+			return false, false
+		}
+		if i -= columns; i < 0 {
+			// Go round to the last row.
+			i = minInt((n-1)/columns*columns+i+columns, n-1)
+		}
+	case Right:
+		if !completing {
+			return false, false
+		}
+		i = (i + 1) % n
+	case Left:
+		if !completing {
+			return false, false
+		}
+		i = (i + n - 1) % n
+	default:
+		return false, false
+	}
+	c.selectGrid(i, columns)
+	return true, true
+}
+
+// renderCompletionGrid renders the suggestions in the grid from the beginning of the row below the cursor,
+// and the description of the selected one below the grid. They are rendered above the cursor
+// when there is room only above. It returns the height of them below the cursor.
+func (r *Render) renderCompletionGrid(buf *Buffer, completions *CompletionManager, suggestions []Suggest, highlight bool) int {
+	width := int(r.col) - 1
+	cells, columns := formatGrid(suggestions, width)
+	if columns == 0 {
+		return 0
+	}
+	rows := (len(cells) + columns - 1) / columns
+	windowRows := minInt(rows, int(completions.max))
+	selected := completions.selected
+	scroll := completions.verticalScroll
+	if selected >= 0 && selected/columns < scroll {
+		scroll = selected / columns
+	} else if selected >= 0 && selected/columns >= scroll+windowRows {
+		scroll = selected/columns - windowRows + 1
+	}
+	scroll = minInt(scroll, rows-windowRows)
+
+	var footer string
+	if selected >= 0 && selected < len(suggestions) {
+		footer = runewidth.Truncate(singleLine(suggestions[selected].Description), width, shortenSuffix)
+	}
+	height := windowRows
+	if footer != "" {
+		height++
+	}
+
+	cursor := r.inputPosition(buf.Document().TextBeforeCursor())
+	_, y := r.toPos(cursor)
+	top := y + 1
+	above := r.placeMenuAbove(y, height)
+	if above {
+		top = y - height
+		r.menuAbove = maxInt(height-y, 0)
+		// Shift the positions so that they aren't negative above the input.
+		cursor += r.menuAbove * int(r.col)
+		top += r.menuAbove
+	} else {
+		r.prepareArea(height)
+	}
+	pos := cursor
+	for i := 0; i < windowRows; i++ {
+		pos = r.move(pos, (top+i)*int(r.col))
+		for j := 0; j < columns; j++ {
+			k := (scroll+i)*columns + j
+			if k >= len(cells) {
+				break
+			}
+			var matches []int
+			if highlight {
+				matches = formatMatches(suggestions[k], completions.matchesOf(buf.Document(), suggestions[k]), runewidth.StringWidth(cells[k].Text))
+			}
+			if k == selected {
+				r.renderSuggestionText(cells[k].Text, matches, r.selectedSuggestionTextColor, r.selectedSuggestionBGColor, true)
+			} else {
+				r.renderSuggestionText(cells[k].Text, matches, r.suggestionTextColor, r.suggestionBGColor, false)
+			}
+			pos += runewidth.StringWidth(cells[k].Text)
+		}
+		r.out.SetColor(DefaultColor, DefaultColor, false)
+	}
+	if footer != "" {
+		pos = r.move(pos, (top+windowRows)*int(r.col))
+		r.out.SetColor(r.descriptionTextColor, r.descriptionBGColor, false)
+		r.out.WriteStr(footer + strings.Repeat(" ", width-runewidth.StringWidth(footer)))
+		r.out.SetColor(DefaultColor, DefaultColor, false)
+		pos += width
+	}
+	r.move(pos, cursor)
+	if above {
+		return 0
+	}
+	return height
+}
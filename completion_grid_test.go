@@ -0,0 +1,98 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatGrid(t *testing.T) {
+	in := []Suggest{{Text: "foo"}, {Text: "bar", Display: "bar/"}, {Text: "bazqux", Description: "not displayed"}}
+	cells, columns := formatGrid(in, 20)
+	expected := []Suggest{{Text: " foo    "}, {Text: " bar/   "}, {Text: " bazqux "}}
+	if !reflect.DeepEqual(cells, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, cells)
+	}
+	if columns != 2 {
+		t.Errorf("Should be %#v, but got %#v", 2, columns)
+	}
+	if _, columns := formatGrid(in, 3); columns != 0 {
+		t.Errorf("Should be %#v, but got %#v", 0, columns)
+	}
+}
+
+func TestGridKeyBinding(t *testing.T) {
+	// 7 suggestions in 3 columns:
+	//   0 1 2
+	//   3 4 5
+	//   6
+	scenarioTable := []struct {
+		name     string
+		selected int
+		key      Key
+		expected int
+		consumed bool
+	}{
+		{name: "tab", selected: -1, key: Tab, expected: 0},
+		{name: "tab at the end", selected: 6, key: Tab, expected: -1},
+		{name: "back tab", selected: -1, key: BackTab, expected: 6},
+		{name: "right", selected: 2, key: Right, expected: 3, consumed: true},
+		{name: "right at the end", selected: 6, key: Right, expected: 0, consumed: true},
+		{name: "left", selected: 0, key: Left, expected: 6, consumed: true},
+		{name: "down", selected: 1, key: Down, expected: 4, consumed: true},
+		{name: "down to the short row", selected: 5, key: Down, expected: 6, consumed: true},
+		{name: "down at the last row", selected: 6, key: Down, expected: 0, consumed: true},
+		{name: "up", selected: 4, key: Up, expected: 1, consumed: true},
+		{name: "up to the short row", selected: 2, key: Up, expected: 6, consumed: true},
+		{name: "up to the last row", selected: 0, key: Up, expected: 6, consumed: true},
+		{name: "left without selection", selected: -1, key: Left, expected: -1},
+	}
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newMockPrompt(nil, newMockParser(), OptionCompletionLayout(CompletionGrid))
+			// Each cell is 6 columns wide, so that 3 cells fit in the window.
+			p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 20})
+			p.completion.tmp = []Suggest{{Text: "aaaa"}, {Text: "bbbb"}, {Text: "cccc"}, {Text: "dddd"}, {Text: "eeee"}, {Text: "ffff"}, {Text: "gggg"}}
+			p.completion.selected = s.selected
+
+			if consumed := p.handleCompletionKeyBinding(s.key, p.completion.Completing()); consumed != s.consumed {
+				t.Errorf("Should be %#v, but got %#v", s.consumed, consumed)
+			}
+			if p.completion.selected != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, p.completion.selected)
+			}
+		})
+	}
+}
+
+func TestGridScroll(t *testing.T) {
+	c := NewCompletionManager(nil, 2)
+	c.tmp = make([]Suggest, 10)
+	for _, s := range []struct{ selected, scroll int }{{2, 0}, {4, 0}, {9, 2}, {3, 1}, {0, 0}} {
+		c.selectGrid(s.selected, 3)
+		if c.verticalScroll != s.scroll {
+			t.Errorf("Should be %#v, but got %#v", s.scroll, c.verticalScroll)
+		}
+	}
+}
+
+func TestRenderCompletionGrid(t *testing.T) {
+	w := &recordingWriter{}
+	p := newMockPrompt(nil, newMockParser(), OptionWriter(w), OptionCompletionLayout(CompletionGrid), OptionPrefix(""))
+	p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 20})
+	p.completion.tmp = []Suggest{{Text: "ab", Description: "first"}, {Text: "cd"}, {Text: "ef"}, {Text: "gh"}, {Text: "ij"}, {Text: "kl"}, {Text: "mn"}}
+	p.completion.selected = 0
+	p.renderer.Render(p.buf, p.completion)
+	expected := "\x1b[?25l\x1b[0;94;49m\x1b[0;39;49m\x1b[0;92;49mab\x1b[0;39;49m\x1b[J" +
+		// Go back to the cursor before the preview of the selected one.
+		"\x1b[2D\x1bD\x1bD\x1bD\x1bM\x1bM\x1bM" +
+		// The grid of 4 columns, each of which is 4 columns wide.
+		"\x1b[1B\x1b[1;30;106m ab \x1b[0;97;46m cd \x1b[0;97;46m ef \x1b[0;97;46m gh \x1b[0;39;49m" +
+		"\x1b[1B\x1b[16D\x1b[0;97;46m ij \x1b[0;97;46m kl \x1b[0;97;46m mn \x1b[0;39;49m" +
+		// The description of the selected one.
+		"\x1b[1B\x1b[12D\x1b[0;30;106mfirst              \x1b[0;39;49m" +
+		// Go back to the cursor, and then after the preview.
+		"\x1b[3A\x1b[19D\x1b[2C\x1b[?12l\x1b[?25h"
+	if actual := string(w.flushed); actual != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+}
@@ -0,0 +1,48 @@
+package prompt
+
+import (
+	"strings"
+)
+
+// insertCommonPrefix inserts the suggestion if it's only one, or the longest common prefix
+// of the suggestions if it's longer than the text they replace like bash.
+// It returns false when there is nothing to insert so that the menu is opened.
+func (p *Prompt) insertCommonPrefix() bool {
+	suggestions := p.completion.GetSuggestions()
+	if len(suggestions) == 0 {
+		return false
+	}
+	if len(suggestions) == 1 {
+		p.insertSuggestion(suggestions[0])
+		p.completion.Reset()
+		return true
+	}
+
+	d := p.buf.Document()
+	start, end := suggestions[0].replacedRange(d, p.completion.wordSeparator)
+	prefix := suggestions[0].Text
+	for _, s := range suggestions[1:] {
+		if from, to := s.replacedRange(d, p.completion.wordSeparator); from != start || to != end {
+			return false
+		}
+		prefix = commonPrefix(prefix, s.Text)
+	}
+	replaced := string([]rune(d.Text)[start:end])
+	if len([]rune(prefix)) <= len([]rune(replaced)) || !strings.HasPrefix(strings.ToLower(prefix), strings.ToLower(replaced)) {
+		return false
+	}
+	p.insertSuggestion(Suggest{Text: prefix, Replace: suggestions[0].Replace})
+	return true
+}
+
+// commonPrefix returns the longest common prefix of a and b.
+func commonPrefix(a, b string) string {
+	ar, br := []rune(a), []rune(b)
+	n := minInt(len(ar), len(br))
+	for i := 0; i < n; i++ {
+		if ar[i] != br[i] {
+			return string(ar[:i])
+		}
+	}
+	return string(ar[:n])
+}
@@ -0,0 +1,63 @@
+package prompt
+
+import (
+	"testing"
+)
+
+func TestCompleteCommonPrefix(t *testing.T) {
+	files := []Suggest{{Text: "README.md"}, {Text: "readme.txt"}, {Text: "render.go"}, {Text: "render_test.go"}, {Text: "main.go"}}
+	completer := func(d Document) []Suggest {
+		return FilterHasPrefix(files, d.GetWordBeforeCursorUntilSeparator(" /"), true)
+	}
+	scenarioTable := []struct {
+		name     string
+		input    string
+		tabs     int
+		expected string
+		selected int
+	}{
+		{name: "common prefix", input: "cat ./ren", tabs: 1, expected: "cat ./render", selected: -1},
+		{name: "single match", input: "cat ./m", tabs: 1, expected: "cat ./main.go", selected: -1},
+		{name: "open the menu", input: "cat ./re", tabs: 1, expected: "cat ./re", selected: 0},
+		{name: "cycle the menu", input: "cat ./render", tabs: 2, expected: "cat ./render", selected: 1},
+		{name: "ignore case", input: "cat ./readme.", tabs: 1, expected: "cat ./readme.", selected: 0},
+		{name: "no match", input: "cat ./x", tabs: 1, expected: "cat ./x", selected: -1},
+	}
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := New(nil, completer, OptionParser(newMockParser()), OptionWriter(&mockWriter{}),
+				OptionCompletionWordSeparator(" /"), OptionCompleteCommonPrefix())
+			p.buf.InsertText(s.input, false, true)
+			p.completion.Update(*p.buf.Document())
+			for i := 0; i < s.tabs; i++ {
+				if _, err := p.feed(NewKeyEvent([]byte{0x9})); err != nil {
+					t.Fatal(err)
+				}
+				p.completion.Update(*p.buf.Document())
+			}
+			if actual := p.buf.Text(); actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+			if p.completion.selected != s.selected {
+				t.Errorf("Should be %#v, but got %#v", s.selected, p.completion.selected)
+			}
+		})
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	scenarioTable := []struct {
+		a, b     string
+		expected string
+	}{
+		{a: "render.go", b: "render_test.go", expected: "render"},
+		{a: "abc", b: "ab", expected: "ab"},
+		{a: "こんにちは", b: "こんばんは", expected: "こん"},
+		{a: "abc", b: "", expected: ""},
+	}
+	for _, s := range scenarioTable {
+		if actual := commonPrefix(s.a, s.b); actual != s.expected {
+			t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+		}
+	}
+}
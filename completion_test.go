@@ -26,6 +26,18 @@ func TestFormatShortSuggestion(t *testing.T) {
 			max:     100,
 			exWidth: 6,
 		},
+		{
+			in: []Suggest{
+				{Text: "foo", Display: "foo/"},
+				{Text: "bar"},
+			},
+			expected: []Suggest{
+				{Text: " foo/ "},
+				{Text: " bar  "},
+			},
+			max:     100,
+			exWidth: 6,
+		},
 		{
 			in: []Suggest{
 				{Text: "apple", Description: "This is apple."},
@@ -117,6 +129,83 @@ func TestFormatShortSuggestion(t *testing.T) {
 	}
 }
 
+func TestSuggestReplace(t *testing.T) {
+	scenarioTable := []struct {
+		name         string
+		text         string
+		cursor       int
+		suggest      Suggest
+		expected     string
+		expectedFrom int
+		expectedTo   int
+	}{
+		{
+			name:         "word before the cursor",
+			text:         "user.na me",
+			cursor:       7,
+			suggest:      Suggest{Text: "name"},
+			expected:     "user.name me",
+			expectedFrom: 5,
+			expectedTo:   9,
+		},
+		{
+			name:         "around the cursor",
+			text:         "user.na me",
+			cursor:       7,
+			suggest:      Suggest{Text: "name", Replace: &ReplaceRange{Start: -2, End: 3}},
+			expected:     "user.name",
+			expectedFrom: 5,
+			expectedTo:   9,
+		},
+		{
+			name:         "out of the text",
+			text:         "\"foo",
+			cursor:       4,
+			suggest:      Suggest{Text: "'foo bar'", Replace: &ReplaceRange{Start: -10, End: 10}},
+			expected:     "'foo bar'",
+			expectedFrom: 0,
+			expectedTo:   9,
+		},
+		{
+			name:         "insert",
+			text:         "ab",
+			cursor:       1,
+			suggest:      Suggest{Text: "x", Replace: &ReplaceRange{}},
+			expected:     "axb",
+			expectedFrom: 1,
+			expectedTo:   2,
+		},
+	}
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newMockPrompt(nil, newMockParser(), OptionCompletionWordSeparator("."))
+			p.buf.InsertText(s.text, false, false)
+			p.buf.setCursorPosition(s.cursor)
+			p.completion.tmp = []Suggest{s.suggest}
+			p.completion.selected = 0
+
+			text, tokens, cursor := p.renderer.displayedInput(p.buf, p.completion)
+			if text != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, text)
+			}
+			if cursor != s.expectedTo {
+				t.Errorf("Should be %#v, but got %#v", s.expectedTo, cursor)
+			}
+			if tokens[0].Start != s.expectedFrom || tokens[0].End != s.expectedTo {
+				t.Errorf("Should be %#v, but got %#v", []int{s.expectedFrom, s.expectedTo}, []int{tokens[0].Start, tokens[0].End})
+			}
+
+			p.handleCompletionKeyBinding(ControlE, true)
+			if actual := p.buf.Text(); actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+			if actual := p.buf.Document().cursorPosition; actual != s.expectedTo {
+				t.Errorf("Should be %#v, but got %#v", s.expectedTo, actual)
+			}
+		})
+	}
+}
+
 func TestFormatText(t *testing.T) {
 	var scenarioTable = []struct {
 		in       []string
@@ -0,0 +1,71 @@
+package prompt
+
+// The methods in this file change a running Prompt from other goroutines.
+// They queue a request to the main loop, so that only it touches the buffer and the renderer.
+// They return without waiting for the main loop, so they can also be called from a KeyBindFunc or a Completer.
+// The methods returning bool return false without doing anything if the prompt is not reading input,
+// e.g. it's not started yet or the Executor is running. True only means the request is queued;
+// the requests left when the prompt returns are discarded.
+
+// SetText replaces the input with text and moves the cursor to cursorPosition,
+// which is counted in runes and clamped to the text.
+func (p *Prompt) SetText(text string, cursorPosition int) bool {
+	return p.request(func() *Exec {
+		if p.search.active {
+			p.endSearch(false)
+		}
+		b := NewBuffer()
+		b.InsertText(text, false, false)
+		b.cursorPosition = maxInt(0, minInt(cursorPosition, len([]rune(text))))
+		p.setBuffer(b)
+		p.completion.Reset()
+		p.completion.refresh(*p.buf.Document())
+		p.updateAutoSuggestion()
+		return nil
+	})
+}
+
+// Refresh redraws the prompt, e.g. after the live prefix changes.
+func (p *Prompt) Refresh() bool {
+	return p.request(func() *Exec { return nil })
+}
+
+// Invalidate asks the prompt to redraw without waiting for it.
+// Invalidations before the next redraw are coalesced into one, and a redraw by keys satisfies them.
+func (p *Prompt) Invalidate() {
+	select {
+	case p.invalidated <- struct{}{}:
+	default:
+	}
+}
+
+// ShowCompletions updates the suggestions by the completer and shows them.
+func (p *Prompt) ShowCompletions() bool {
+	return p.request(func() *Exec {
+		p.completion.refresh(*p.buf.Document())
+		return nil
+	})
+}
+
+// Accept submits the current input as Enter key does, but without the Validator.
+func (p *Prompt) Accept() bool {
+	return p.request(func() *Exec {
+		if p.search.active {
+			p.endSearch(true)
+		}
+		// Insert the selected suggestion like Enter key.
+		p.handleCompletionKeyBinding(Enter, p.completion.Completing())
+		return p.submit()
+	})
+}
+
+// Abort discards the current input as Ctrl+C does.
+func (p *Prompt) Abort() bool {
+	return p.request(func() *Exec {
+		if p.search.active {
+			p.endSearch(false)
+		}
+		p.abort()
+		return nil
+	})
+}
@@ -0,0 +1,140 @@
+package prompt
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// startMockPrompt runs p.InputContext in a goroutine and returns the channel of its result.
+// It returns after the prompt starts reading input.
+func startMockPrompt(p *Prompt, m *mockParser) chan string {
+	done := make(chan string)
+	go func() {
+		in, _ := p.InputContext(context.Background())
+		done <- in
+	}()
+	// The unbuffered input is received after the prompt starts reading input.
+	m.input <- []byte{}
+	return done
+}
+
+// sendMockInput sends input and returns after it's queued to the main loop.
+func sendMockInput(m *mockParser, input string) {
+	m.input <- []byte(input)
+	// The next input is read after the previous one is queued. Empty input is ignored.
+	m.input <- []byte{}
+}
+
+func TestPromptSetTextAndAccept(t *testing.T) {
+	m := newMockParser()
+	p := newMockPrompt(nil, m)
+	done := startMockPrompt(p, m)
+
+	if !p.SetText("hello world", 5) {
+		t.Fatalf("Should be %#v, but got %#v", true, false)
+	}
+	sendMockInput(m, "!")
+	if !p.Accept() {
+		t.Errorf("Should be %#v, but got %#v", true, false)
+	}
+	if in := <-done; in != "hello! world" {
+		t.Errorf("Should be %#v, but got %#v", "hello! world", in)
+	}
+	if p.Refresh() {
+		t.Errorf("Should be %#v, but got %#v", false, true)
+	}
+}
+
+func TestPromptAbort(t *testing.T) {
+	m := newMockParser()
+	p := newMockPrompt(nil, m)
+	done := startMockPrompt(p, m)
+
+	sendMockInput(m, "foo")
+	if !p.Abort() {
+		t.Errorf("Should be %#v, but got %#v", true, false)
+	}
+	m.input <- []byte("bar\n")
+	if in := <-done; in != "bar" {
+		t.Errorf("Should be %#v, but got %#v", "bar", in)
+	}
+}
+
+func TestPromptShowCompletions(t *testing.T) {
+	m := newMockParser()
+	candidates := []Suggest{{Text: "foo"}}
+	var suggestions []Suggest
+	p := New(nil, func(Document) []Suggest { return suggestions }, OptionParser(m), OptionWriter(&mockWriter{}))
+	done := startMockPrompt(p, m)
+
+	suggestions = candidates
+	if !p.ShowCompletions() {
+		t.Errorf("Should be %#v, but got %#v", true, false)
+	}
+	if !p.Refresh() {
+		t.Errorf("Should be %#v, but got %#v", true, false)
+	}
+	// Tab selects the suggestion shown by ShowCompletions.
+	sendMockInput(m, "\t")
+	p.Accept()
+	if in := <-done; in != "foo" {
+		t.Errorf("Should be %#v, but got %#v", "foo", in)
+	}
+}
+
+func TestPromptInvalidate(t *testing.T) {
+	rendered := make(chan struct{}, 16)
+	m := newMockParser()
+	p := newMockPrompt(nil, m, OptionLivePrefix(func() (string, bool) {
+		select {
+		case rendered <- struct{}{}:
+		default:
+		}
+		return "", false
+	}))
+
+	// Invalidations are coalesced and don't block before the prompt starts.
+	p.Invalidate()
+	p.Invalidate()
+	if len(p.invalidated) != 1 {
+		t.Errorf("Should be %#v, but got %#v", 1, len(p.invalidated))
+	}
+	<-p.invalidated
+
+	done := startMockPrompt(p, m)
+	for len(rendered) > 0 {
+		<-rendered
+	}
+	p.Invalidate()
+	select {
+	case <-rendered:
+	case <-time.After(time.Second):
+		t.Errorf("Should redraw the prompt after Invalidate")
+	}
+	m.input <- []byte("\n")
+	<-done
+}
+
+func TestPromptRefreshInterval(t *testing.T) {
+	rendered := make(chan struct{}, 16)
+	m := newMockParser()
+	p := newMockPrompt(nil, m, OptionRefreshInterval(time.Millisecond), OptionLivePrefix(func() (string, bool) {
+		select {
+		case rendered <- struct{}{}:
+		default:
+		}
+		return "", false
+	}))
+
+	done := startMockPrompt(p, m)
+	for i := 0; i < 5; i++ {
+		select {
+		case <-rendered:
+		case <-time.After(time.Second):
+			t.Fatalf("Should redraw the prompt periodically")
+		}
+	}
+	m.input <- []byte("\n")
+	<-done
+}
@@ -0,0 +1,141 @@
+package prompt
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/c-bata/go-prompt/internal/debug"
+)
+
+// cprDrainTimeout is how long the prompt waits for the report in flight before it stops reading input.
+const cprDrainTimeout = 100 * time.Millisecond
+
+// parseCPR parses a cursor position report "CSI <row> ; <col> R".
+func parseCPR(b []byte) (row, col int, ok bool) {
+	if len(b) < 6 || b[0] != 0x1b || b[1] != '[' || b[len(b)-1] != 'R' {
+		return 0, 0, false
+	}
+	var params []int
+	start := 2
+	for i := 2; i < len(b); i++ {
+		if b[i] != ';' && i != len(b)-1 {
+			continue
+		}
+		n, err := strconv.Atoi(string(b[start:i]))
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		params = append(params, n)
+		start = i + 1
+	}
+	if len(params) != 2 {
+		return 0, 0, false
+	}
+	return params[0], params[1], true
+}
+
+// askForCPR asks the terminal for the position of the cursor, so that the completion menu
+// can be placed above the input near the bottom of the window.
+// It doesn't ask again until the report arrives, because some terminals never report it.
+func (r *Render) askForCPR() {
+	if r.col == 0 || r.cprPending {
+		return
+	}
+	r.cprPending = true
+	r.cprCursor = r.previousCursor
+	r.out.AskForCPR()
+	debug.AssertNoError(r.out.Flush())
+}
+
+// handleCPR updates the row of the input on the screen by ev if it's the report asked by askForCPR.
+// It returns true when ev is consumed.
+func (r *Render) handleCPR(ev KeyEvent) bool {
+	if !r.cprPending {
+		return false
+	}
+	row, _, ok := parseCPR(ev.ASCIICode)
+	if !ok {
+		return false
+	}
+	r.cprPending = false
+	if r.cprCursor >= 0 && r.col != 0 {
+		_, y := r.toPos(r.cprCursor)
+		r.screenRow = row - y
+	}
+	return true
+}
+
+// cancelCPR forgets the report in flight, e.g. when the prompt stops reading input and the report
+// may be read by others. askForCPR asks again next time.
+func (r *Render) cancelCPR() {
+	r.cprPending = false
+	r.cprCursor = -1
+}
+
+// drainCPR waits for the report in flight for a while before the prompt stops reading input,
+// so that it isn't echoed or read by the Executor after the terminal leaves raw mode.
+// The keys and events arriving meanwhile are put back to the front of the queue in order.
+func (p *Prompt) drainCPR() {
+	if !p.renderer.cprPending {
+		return
+	}
+	var kept []event
+	defer func() { p.events.pushFront(kept...) }()
+	timeout := time.After(cprDrainTimeout)
+	for p.renderer.cprPending {
+		select {
+		case <-timeout:
+			return
+		case <-p.events.ready:
+		}
+		ev, ok := p.events.pop()
+		if !ok {
+			continue
+		}
+		if ev.kind != eventInput {
+			kept = append(kept, ev)
+			continue
+		}
+		var keys []KeyEvent
+		for _, k := range p.decoder.Feed(ev.input) {
+			if !p.renderer.handleCPR(k) {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) > 0 {
+			kept = append(kept, event{kind: eventKeys, keys: keys})
+		}
+	}
+}
+
+// forgetScreenRow is called when the input moves on the screen, e.g. it's broken or printed above.
+// The report in flight is ignored because it tells the old position.
+func (r *Render) forgetScreenRow() {
+	r.screenRow = 0
+	r.cprCursor = -1
+}
+
+// placeMenuAbove returns whether the menu of height rows is placed above the cursor at the row cursorY of the input.
+// It's placed above only when the row of the input on the screen is known,
+// and there isn't enough room below the cursor but there is above it.
+func (r *Render) placeMenuAbove(cursorY, height int) bool {
+	if r.screenRow == 0 {
+		return false
+	}
+	row := r.screenRow - 1 + cursorY // 0-based
+	return int(r.row)-row-1 < height && row >= height
+}
+
+// clearMenuAbove erases the rows above the input which the completion menu covered.
+// The cursor must be at the beginning of the input.
+func (r *Render) clearMenuAbove() {
+	if r.menuAbove == 0 {
+		return
+	}
+	r.out.CursorUp(r.menuAbove)
+	for i := 0; i < r.menuAbove; i++ {
+		r.out.EraseLine()
+		r.out.CursorDown(1)
+	}
+	r.menuAbove = 0
+}
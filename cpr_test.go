@@ -0,0 +1,212 @@
+package prompt
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseCPR(t *testing.T) {
+	scenarioTable := []struct {
+		input string
+		row   int
+		col   int
+		ok    bool
+	}{
+		{input: "\x1b[12;5R", row: 12, col: 5, ok: true},
+		{input: "\x1b[1;1R", row: 1, col: 1, ok: true},
+		{input: "\x1b[12R"},
+		{input: "\x1b[1;2;3R"},
+		{input: "\x1b[a;5R"},
+		{input: "\x1b[12;5~"},
+	}
+	for _, s := range scenarioTable {
+		row, col, ok := parseCPR([]byte(s.input))
+		if row != s.row || col != s.col || ok != s.ok {
+			t.Errorf("%q: Should be %#v, but got %#v", s.input, []interface{}{s.row, s.col, s.ok}, []interface{}{row, col, ok})
+		}
+	}
+}
+
+func TestPromptCPR(t *testing.T) {
+	p := newMockPrompt(nil, newMockParser())
+	p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 10})
+	p.buf.InsertText("0123456789abc", false, true)
+	p.renderer.Render(p.buf, p.completion)
+
+	p.renderer.askForCPR()
+	if !p.renderer.cprPending {
+		t.Fatalf("Should be %#v, but got %#v", true, p.renderer.cprPending)
+	}
+	// The cursor is in the second row of the input.
+	if _, done, err := p.feedKeys([]KeyEvent{NewKeyEvent([]byte("\x1b[20;6R"))}, false); done || err != nil {
+		t.Fatalf("Should be %#v, but got %#v, %#v", false, done, err)
+	}
+	if expected := 19; p.renderer.screenRow != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.renderer.screenRow)
+	}
+	if expected := "0123456789abc"; p.buf.Text() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.buf.Text())
+	}
+
+	// The report which is not asked is handled as a key.
+	if p.renderer.handleCPR(NewKeyEvent([]byte("\x1b[20;6R"))) {
+		t.Errorf("Should be %#v, but got %#v", false, true)
+	}
+
+	// The report in flight when the input moves is ignored.
+	p.renderer.askForCPR()
+	p.renderer.BreakLine(p.buf)
+	if !p.renderer.handleCPR(NewKeyEvent([]byte("\x1b[21;1R"))) {
+		t.Errorf("Should be %#v, but got %#v", true, false)
+	}
+	if p.renderer.screenRow != 0 {
+		t.Errorf("Should be %#v, but got %#v", 0, p.renderer.screenRow)
+	}
+}
+
+func TestPlaceMenuAbove(t *testing.T) {
+	scenarioTable := []struct {
+		screenRow int
+		cursorY   int
+		expected  bool
+	}{
+		{screenRow: 0, cursorY: 5, expected: false},
+		{screenRow: 1, cursorY: 0, expected: false},
+		{screenRow: 4, cursorY: 0, expected: true},
+		{screenRow: 2, cursorY: 2, expected: true},
+		// There is no room on either side.
+		{screenRow: 3, cursorY: 0, expected: false},
+	}
+	for _, s := range scenarioTable {
+		r := &Render{row: 5, screenRow: s.screenRow}
+		if actual := r.placeMenuAbove(s.cursorY, 3); actual != s.expected {
+			t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+		}
+	}
+}
+
+func TestRenderCompletionAbove(t *testing.T) {
+	suggestions := []Suggest{{Text: "foo"}, {Text: "bar"}}
+	scenarioTable := []struct {
+		name      string
+		screenRow int
+		layout    CompletionLayout
+		expected  string
+		menuAbove int
+	}{
+		{
+			name:      "unknown",
+			screenRow: 0,
+			// Scroll the screen to make room below if needed.
+			expected: "\x1bD\x1bD\x1bM\x1bM\x1b[0;97;46m" +
+				"\x1b[1B\x1b[0;97;46m foo \x1b[0;39;49m\x1b[0;39;49m \x1b[0;39;49m\x1b[6D" +
+				"\x1b[1B\x1b[0;97;46m bar \x1b[0;39;49m\x1b[0;39;49m \x1b[0;39;49m\x1b[6D\x1b[2A\x1b[0;39;49m",
+		},
+		{
+			name:      "room below",
+			screenRow: 5,
+			expected: "\x1bD\x1bD\x1bM\x1bM\x1b[0;97;46m" +
+				"\x1b[1B\x1b[0;97;46m foo \x1b[0;39;49m\x1b[0;39;49m \x1b[0;39;49m\x1b[6D" +
+				"\x1b[1B\x1b[0;97;46m bar \x1b[0;39;49m\x1b[0;39;49m \x1b[0;39;49m\x1b[6D\x1b[2A\x1b[0;39;49m",
+		},
+		{
+			name:      "room only above",
+			screenRow: 10,
+			expected: "\x1b[2A\x1b[0;97;46m" +
+				"\x1b[0;97;46m foo \x1b[0;39;49m\x1b[0;39;49m \x1b[0;39;49m\x1b[6D" +
+				"\x1b[1B\x1b[0;97;46m bar \x1b[0;39;49m\x1b[0;39;49m \x1b[0;39;49m\x1b[6D\x1b[1B\x1b[0;39;49m",
+			menuAbove: 2,
+		},
+		{
+			name:      "grid above",
+			screenRow: 10,
+			layout:    CompletionGrid,
+			// A column of the cells from the beginning of the row.
+			expected:  "\x1b[2A\x1b[2D\x1b[0;97;46m foo \x1b[0;39;49m\x1b[1B\x1b[5D\x1b[0;97;46m bar \x1b[0;39;49m\x1b[1B\x1b[3D",
+			menuAbove: 2,
+		},
+	}
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			w := &mockWriter{}
+			r := &Render{
+				prefix:              "> ",
+				out:                 w,
+				livePrefixCallback:  func() (string, bool) { return "", false },
+				row:                 10,
+				col:                 10,
+				screenRow:           s.screenRow,
+				suggestionTextColor: White,
+				suggestionBGColor:   Cyan,
+			}
+			c := NewCompletionManager(nil, 2)
+			c.tmp = suggestions
+			c.layout = s.layout
+			r.renderCompletion(NewBuffer(), c)
+			if actual := string(w.buffer); actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+			if r.menuAbove != s.menuAbove {
+				t.Errorf("Should be %#v, but got %#v", s.menuAbove, r.menuAbove)
+			}
+
+			// The rows above the input are erased by the next rendering.
+			w.buffer = nil
+			r.clearMenuAbove()
+			expected := ""
+			if s.menuAbove > 0 {
+				expected = "\x1b[" + strconv.Itoa(s.menuAbove) + "A" + strings.Repeat("\x1b[2K\x1b[1B", s.menuAbove)
+			}
+			if string(w.buffer) != expected {
+				t.Errorf("Should be %#v, but got %#v", expected, string(w.buffer))
+			}
+		})
+	}
+}
+
+func TestPromptCPRAcrossExecutor(t *testing.T) {
+	w := &recordingWriter{}
+	m := newMockParser()
+	var (
+		p       *Prompt
+		inputs  []string
+		pending []bool
+	)
+	executed := make(chan struct{}, 3)
+	p = newMockPrompt(func(in string) {
+		inputs = append(inputs, in)
+		pending = append(pending, p.renderer.cprPending)
+		executed <- struct{}{}
+	}, m, OptionWriter(w))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- p.RunContext(ctx) }()
+
+	// Enter arrives between the request at start and the report.
+	sendMockInput(m, "ab\r")
+	sendMockInput(m, "\x1b[5;3R")
+	// The report of the request after the Executor never arrives.
+	sendMockInput(m, "cd\r")
+	sendMockInput(m, "ef\r")
+	for i := 0; i < 3; i++ {
+		<-executed
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Should be %#v, but got %#v", context.Canceled, err)
+	}
+
+	if expected := []string{"ab", "cd", "ef"}; !reflect.DeepEqual(inputs, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, inputs)
+	}
+	if expected := []bool{false, false, false}; !reflect.DeepEqual(pending, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, pending)
+	}
+	// It asks again after each Executor.
+	if n := strings.Count(string(w.flushed), "\x1b[6n"); n != 4 {
+		t.Errorf("Should be %#v, but got %#v", 4, n)
+	}
+}
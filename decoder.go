@@ -0,0 +1,199 @@
+package prompt
+
+import (
+	"bytes"
+	"time"
+	"unicode/utf8"
+)
+
+// escapeTimeout is how long the decoder waits for the rest of an escape sequence.
+// When it expires, a pending ESC is reported as Escape key.
+const escapeTimeout = 50 * time.Millisecond
+
+// pasteTimeout is how long the decoder waits for the rest of a bracketed paste.
+// When it expires, the text received so far is reported as BracketedPaste
+// so that a missing end marker doesn't swallow the following input.
+const pasteTimeout = time.Second
+
+var (
+	bracketedPasteStart = []byte{0x1b, '[', '2', '0', '0', '~'}
+	bracketedPasteEnd   = []byte{0x1b, '[', '2', '0', '1', '~'}
+)
+
+// inputDecoder splits a byte stream read from ConsoleParser into key events.
+// A single read may contain several keys (e.g. a paste or fast typing),
+// and an escape sequence or a UTF-8 character may be split across reads.
+// Text between bracketed paste markers becomes a single BracketedPaste event.
+type inputDecoder struct {
+	pending []byte
+	pasting bool
+}
+
+// Feed decodes b following the pending bytes.
+// The incomplete sequence at the end is kept until the next Feed or Flush.
+func (d *inputDecoder) Feed(b []byte) []KeyEvent {
+	d.pending = append(d.pending, b...)
+	return d.decode(false)
+}
+
+// Flush decodes all pending bytes even if the last sequence or the bracketed paste is incomplete.
+// It should be called when no more input arrives within Timeout.
+func (d *inputDecoder) Flush() []KeyEvent {
+	return d.decode(true)
+}
+
+// Pending returns whether the decoder waits for the rest of a sequence or a bracketed paste.
+func (d *inputDecoder) Pending() bool {
+	return len(d.pending) > 0 || d.pasting
+}
+
+// Timeout returns how long the decoder waits for the rest of the pending input before Flush.
+func (d *inputDecoder) Timeout() time.Duration {
+	if d.pasting {
+		return pasteTimeout
+	}
+	return escapeTimeout
+}
+
+func (d *inputDecoder) decode(flush bool) []KeyEvent {
+	var keys []KeyEvent
+	b := d.pending
+	for len(b) > 0 {
+		if d.pasting {
+			i := bytes.Index(b, bracketedPasteEnd)
+			end := i + len(bracketedPasteEnd)
+			if i == -1 {
+				if !flush {
+					break
+				}
+				// The end marker is missing.
+				i, end = len(b), len(b)
+			}
+			text := make([]byte, i)
+			copy(text, b[:i])
+			keys = append(keys, KeyEvent{Key: BracketedPaste, ASCIICode: text})
+			b = b[end:]
+			d.pasting = false
+			continue
+		}
+
+		n := sequenceLength(b, flush)
+		if n == 0 {
+			break
+		}
+		if bytes.Equal(b[:n], bracketedPasteStart) {
+			d.pasting = true
+			b = b[n:]
+			continue
+		}
+		seq := make([]byte, n)
+		copy(seq, b[:n])
+		keys = append(keys, NewKeyEvent(seq))
+		b = b[n:]
+	}
+	if flush {
+		d.pasting = false
+	}
+	if len(b) == 0 {
+		d.pending = nil
+	} else {
+		d.pending = append([]byte{}, b...)
+	}
+	return keys
+}
+
+// sequenceLength returns the length of the first key in b.
+// It returns 0 if b may be the beginning of a longer sequence and flush is false.
+func sequenceLength(b []byte, flush bool) int {
+	if b[0] != 0x1b {
+		if b[0] < utf8.RuneSelf {
+			return 1
+		}
+		if !flush && !utf8.FullRune(b) {
+			return 0
+		}
+		_, n := utf8.DecodeRune(b)
+		return n
+	}
+
+	n, complete := escapeSequenceLength(b)
+	if !flush && (!complete || hasLongerSequence(b)) {
+		return 0
+	}
+	if m := longestSequence(b); m > n || !complete {
+		n = m
+	}
+	if n == 0 {
+		// Report an incomplete escape sequence as Escape key and the rest as usual input.
+		n = 1
+	}
+	return n
+}
+
+// escapeSequenceLength parses b which starts with ESC following the syntax of
+// CSI (ESC [ ...), SS3 (ESC O x) or a Meta-prefixed character (ESC x).
+func escapeSequenceLength(b []byte) (n int, complete bool) {
+	if len(b) < 2 {
+		return 1, false
+	}
+	switch b[1] {
+	case 0x1b:
+		// Double escape. The first one is Escape key.
+		return 1, true
+	case '[':
+		if len(b) > 2 && b[2] == '[' {
+			// Linux console sends ESC [ [ A for F1.
+			if len(b) < 4 {
+				return len(b), false
+			}
+			return 4, true
+		}
+		// Parameter bytes, intermediate bytes and a final byte.
+		for i := 2; i < len(b); i++ {
+			switch c := b[i]; {
+			case 0x20 <= c && c <= 0x3f:
+				continue
+			case 0x40 <= c && c <= 0x7e:
+				return i + 1, true
+			default:
+				// Malformed sequence.
+				return i, true
+			}
+		}
+		return len(b), false
+	case 'O':
+		if len(b) < 3 {
+			return len(b), false
+		}
+		return 3, true
+	}
+
+	if b[1] < utf8.RuneSelf {
+		return 2, true
+	}
+	if !utf8.FullRune(b[1:]) {
+		return len(b), false
+	}
+	_, n = utf8.DecodeRune(b[1:])
+	return 1 + n, true
+}
+
+// longestSequence returns the length of the longest ASCIISequences entry which b starts with.
+func longestSequence(b []byte) (n int) {
+	for _, k := range ASCIISequences {
+		if len(k.ASCIICode) > n && bytes.HasPrefix(b, k.ASCIICode) {
+			n = len(k.ASCIICode)
+		}
+	}
+	return n
+}
+
+// hasLongerSequence returns whether b is a part of an ASCIISequences entry.
+func hasLongerSequence(b []byte) bool {
+	for _, k := range ASCIISequences {
+		if len(k.ASCIICode) > len(b) && bytes.HasPrefix(k.ASCIICode, b) {
+			return true
+		}
+	}
+	return false
+}
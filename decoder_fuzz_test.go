@@ -0,0 +1,60 @@
+//go:build go1.18
+// +build go1.18
+
+package prompt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func FuzzInputDecoder(f *testing.F) {
+	for _, k := range ASCIISequences {
+		f.Add(k.ASCIICode, uint8(1))
+	}
+	f.Add([]byte("hello, 日本語"), uint8(3))
+	f.Add([]byte{0x1b, 0x5b, 0x31, 0x3b, 0x35, 0x43, 'a', 0x1b, 'f', 0x1b}, uint8(2))
+	f.Add([]byte("\x1b[200~pasted\nline\x1b[201~a"), uint8(4))
+
+	f.Fuzz(func(t *testing.T, input []byte, step uint8) {
+		var whole inputDecoder
+		expected := append(whole.Feed(input), whole.Flush()...)
+
+		// Splitting the input into reads must not change the result.
+		var split inputDecoder
+		var actual []KeyEvent
+		n := int(step)%7 + 1
+		for i := 0; i < len(input); i += n {
+			end := i + n
+			if end > len(input) {
+				end = len(input)
+			}
+			actual = append(actual, split.Feed(input[i:end])...)
+		}
+		actual = append(actual, split.Flush()...)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Should be %#v, but got %#v", expected, actual)
+		}
+
+		if bytes.Contains(input, bracketedPasteStart) {
+			// Paste markers are not a part of keys.
+			return
+		}
+
+		// Decoded keys must hold all input bytes.
+		var joined []byte
+		for _, k := range expected {
+			if len(k.ASCIICode) == 0 {
+				t.Fatal("Should not return an empty key")
+			}
+			if e := NewKeyEvent(k.ASCIICode); !reflect.DeepEqual(k, e) {
+				t.Errorf("Should be %#v, but got %#v", e, k)
+			}
+			joined = append(joined, k.ASCIICode...)
+		}
+		if !bytes.Equal(joined, input) {
+			t.Errorf("Should be %#v, but got %#v", input, joined)
+		}
+	})
+}
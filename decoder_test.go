@@ -0,0 +1,185 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInputDecoder(t *testing.T) {
+	scenarioTable := []struct {
+		name     string
+		input    [][]byte
+		expected []ASCIICode
+		pending  bool
+	}{
+		{
+			name:  "coalesced characters",
+			input: [][]byte{[]byte("ab")},
+			expected: []ASCIICode{
+				{Key: NotDefined, ASCIICode: []byte("a")},
+				{Key: NotDefined, ASCIICode: []byte("b")},
+			},
+		},
+		{
+			name:  "coalesced escape sequences",
+			input: [][]byte{{0x1b, 0x5b, 0x41, 0x1b, 0x5b, 0x42, 'x'}},
+			expected: []ASCIICode{
+				{Key: Up, ASCIICode: []byte{0x1b, 0x5b, 0x41}},
+				{Key: Down, ASCIICode: []byte{0x1b, 0x5b, 0x42}},
+				{Key: NotDefined, ASCIICode: []byte("x")},
+			},
+		},
+		{
+			name:  "escape sequence split across reads",
+			input: [][]byte{{'a', 0x1b, 0x5b, 0x33}, {0x7e}},
+			expected: []ASCIICode{
+				{Key: NotDefined, ASCIICode: []byte("a")},
+				{Key: Delete, ASCIICode: []byte{0x1b, 0x5b, 0x33, 0x7e}},
+			},
+		},
+		{
+			name:  "unknown CSI sequence",
+			input: [][]byte{{0x1b, 0x5b, 0x39, 0x39, 0x7e, 'a'}},
+			expected: []ASCIICode{
+				{Key: NotDefined, ASCIICode: []byte{0x1b, 0x5b, 0x39, 0x39, 0x7e}},
+				{Key: NotDefined, ASCIICode: []byte("a")},
+			},
+		},
+		{
+			name:  "meta prefixed character",
+			input: [][]byte{{0x1b, 'b', 0x3}},
+			expected: []ASCIICode{
+				{Key: NotDefined, ASCIICode: []byte{0x1b, 'b'}},
+				{Key: ControlC, ASCIICode: []byte{0x3}},
+			},
+		},
+		{
+			name:  "multi-byte character split across reads",
+			input: [][]byte{[]byte("日本")[:4], []byte("日本")[4:]},
+			expected: []ASCIICode{
+				{Key: NotDefined, ASCIICode: []byte("日")},
+				{Key: NotDefined, ASCIICode: []byte("本")},
+			},
+		},
+		{
+			name:     "lone escape",
+			input:    [][]byte{{0x1b}},
+			expected: nil,
+			pending:  true,
+		},
+		{
+			name:     "incomplete SS3 sequence",
+			input:    [][]byte{{0x1b, 0x4f}},
+			expected: nil,
+			pending:  true,
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			var d inputDecoder
+			var actual []ASCIICode
+			for _, b := range s.input {
+				actual = append(actual, toASCIICodes(d.Feed(b))...)
+			}
+			if !reflect.DeepEqual(actual, s.expected) {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+			if d.Pending() != s.pending {
+				t.Errorf("Should be %#v, but got %#v", s.pending, d.Pending())
+			}
+		})
+	}
+}
+
+func TestInputDecoderFlush(t *testing.T) {
+	var d inputDecoder
+	if keys := d.Feed([]byte{0x1b}); len(keys) != 0 {
+		t.Errorf("Should be empty, but got %#v", keys)
+	}
+	expected := []ASCIICode{{Key: Escape, ASCIICode: []byte{0x1b}}}
+	if actual := toASCIICodes(d.Flush()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+
+	d.Feed([]byte{0x1b, 0x5b, 0x31})
+	expected = []ASCIICode{
+		{Key: Escape, ASCIICode: []byte{0x1b}},
+		{Key: NotDefined, ASCIICode: []byte("[")},
+		{Key: NotDefined, ASCIICode: []byte("1")},
+	}
+	if actual := toASCIICodes(d.Flush()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+	if d.Pending() {
+		t.Error("Should not be pending after Flush")
+	}
+}
+
+func TestInputDecoderASCIISequences(t *testing.T) {
+	for _, k := range ASCIISequences {
+		var d inputDecoder
+		actual := append(d.Feed(k.ASCIICode), d.Flush()...)
+		expected := []KeyEvent{NewKeyEvent(k.ASCIICode)}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Should be %#v, but got %#v", expected, actual)
+		}
+	}
+}
+
+func TestInputDecoderBracketedPaste(t *testing.T) {
+	var d inputDecoder
+	keys := d.Feed([]byte("a\x1b[200~foo\rbar\x1b[2"))
+	expected := []ASCIICode{{Key: NotDefined, ASCIICode: []byte("a")}}
+	if actual := toASCIICodes(keys); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+	if !d.Pending() || d.Timeout() != pasteTimeout {
+		t.Errorf("Should be %#v, but got %#v", []interface{}{true, pasteTimeout}, []interface{}{d.Pending(), d.Timeout()})
+	}
+
+	keys = d.Feed([]byte("01~\x1b[A"))
+	expected = []ASCIICode{
+		{Key: BracketedPaste, ASCIICode: []byte("foo\rbar")},
+		{Key: Up, ASCIICode: []byte{0x1b, 0x5b, 0x41}},
+	}
+	if actual := toASCIICodes(keys); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+}
+
+func TestInputDecoderBracketedPasteWithoutEnd(t *testing.T) {
+	var d inputDecoder
+	if keys := d.Feed([]byte("\x1b[200~foo\x1b[A")); len(keys) != 0 {
+		t.Errorf("Should be empty, but got %#v", keys)
+	}
+	// The text is reported as a paste when the end marker doesn't arrive within pasteTimeout.
+	expected := []ASCIICode{{Key: BracketedPaste, ASCIICode: []byte("foo\x1b[A")}}
+	if actual := toASCIICodes(d.Flush()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+	if d.Pending() || d.Timeout() != escapeTimeout {
+		t.Errorf("Should be %#v, but got %#v", []interface{}{false, escapeTimeout}, []interface{}{d.Pending(), d.Timeout()})
+	}
+
+	// The following input is decoded as keys.
+	expected = []ASCIICode{{Key: NotDefined, ASCIICode: []byte("a")}}
+	if actual := toASCIICodes(d.Feed([]byte("a"))); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+
+	// Only the start marker.
+	d.Feed([]byte("\x1b[200~"))
+	if keys := d.Flush(); len(keys) != 0 || d.Pending() {
+		t.Errorf("Should be %#v, but got %#v", []interface{}{0, false}, []interface{}{len(keys), d.Pending()})
+	}
+}
+
+// toASCIICodes converts keys to compare them with ASCIICode literals.
+func toASCIICodes(keys []KeyEvent) []ASCIICode {
+	var codes []ASCIICode
+	for _, k := range keys {
+		codes = append(codes, ASCIICode{Key: k.key(), ASCIICode: k.ASCIICode})
+	}
+	return codes
+}
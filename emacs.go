@@ -17,7 +17,11 @@ Moving the cursor
 * [x] Ctrl + n   Next command (Down arrow)
 * [x] Ctrl + f   Forward one character
 * [x] Ctrl + b   Backward one character
+* [x] Alt  + f   Forward one word
+* [x] Alt  + b   Backward one word
 * [x] Ctrl + xx  Toggle between the start of line and current cursor position
+* [x] Ctrl + r   Search the history backward incrementally
+* [x] Ctrl + s   Search the history forward incrementally
 
 Editing
 -------
@@ -26,15 +30,17 @@ Editing
 * [x] Ctrl + d   Delete character under the cursor
 * [x] Ctrl + h   Delete character before the cursor (Backspace)
 
-* [x] Ctrl + w   Cut the Word before the cursor to the clipboard.
-* [x] Ctrl + k   Cut the Line after the cursor to the clipboard.
-* [x] Ctrl + u   Cut/delete the Line before the cursor to the clipboard.
+* [x] Ctrl + w   Cut the Word before the cursor to the kill ring.
+* [x] Ctrl + k   Cut the Line after the cursor to the kill ring.
+* [x] Ctrl + u   Cut/delete the Line before the cursor to the kill ring.
 
 * [ ] Ctrl + t   Swap the last two characters before the cursor (typo).
 * [ ] Esc  + t   Swap the last two words before the cursor.
 
-* [ ] ctrl + y   Paste the last thing to be cut (yank)
-* [ ] ctrl + _   Undo
+* [x] Ctrl + y   Paste the last thing to be cut (yank)
+* [x] Alt  + y   Replace the yanked text with the previous thing to be cut (yank-pop)
+* [x] Ctrl + _   Undo
+* [x] Ctrl + Alt + _  Redo
 
 */
 
@@ -60,7 +66,7 @@ var emacsKeyBindings = []KeyBind{
 		Key: ControlK,
 		Fn: func(buf *Buffer) {
 			x := []rune(buf.Document().TextAfterCursor())
-			buf.Delete(len(x))
+			buf.KillRing().Kill(buf.Delete(len(x)))
 		},
 	},
 	// Cut/delete the Line before the cursor
@@ -68,7 +74,7 @@ var emacsKeyBindings = []KeyBind{
 		Key: ControlU,
 		Fn: func(buf *Buffer) {
 			x := []rune(buf.Document().TextBeforeCursor())
-			buf.DeleteBeforeCursor(len(x))
+			buf.KillRing().KillBackward(buf.DeleteBeforeCursor(len(x)))
 		},
 	},
 	// Delete character under the cursor
@@ -101,13 +107,52 @@ var emacsKeyBindings = []KeyBind{
 			buf.CursorLeft(1)
 		},
 	},
+	// Forward one word
+	{
+		Rune:      'f',
+		Modifiers: ModAlt,
+		Fn:        GoRightWord,
+	},
+	// Backward one word
+	{
+		Rune:      'b',
+		Modifiers: ModAlt,
+		Fn:        GoLeftWord,
+	},
 	// Cut the Word before the cursor.
 	{
 		Key: ControlW,
 		Fn: func(buf *Buffer) {
-			buf.DeleteBeforeCursor(len([]rune(buf.Document().GetWordBeforeCursorWithSpace())))
+			x := []rune(buf.Document().GetWordBeforeCursorWithSpace())
+			buf.KillRing().KillBackward(buf.DeleteBeforeCursor(len(x)))
+		},
+	},
+	// Paste the last thing to be cut
+	{
+		Key: ControlY,
+		Fn: func(buf *Buffer) {
+			buf.Yank()
 		},
 	},
+	// Replace the yanked text with the previous thing to be cut
+	{
+		Rune:      'y',
+		Modifiers: ModAlt,
+		Fn: func(buf *Buffer) {
+			buf.YankPop()
+		},
+	},
+	// Undo
+	{
+		Key: ControlUnderscore,
+		Fn:  Undo,
+	},
+	// Redo
+	{
+		Key:       ControlUnderscore,
+		Modifiers: ModAlt,
+		Fn:        Redo,
+	},
 	// Clear the Screen, similar to the clear command
 	{
 		Key: ControlL,
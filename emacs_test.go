@@ -22,10 +22,31 @@ func TestEmacsKeyBindings(t *testing.T) {
 	}
 }
 
+func TestEmacsKeyBindingsWithModifiers(t *testing.T) {
+	buf := NewBuffer()
+	buf.InsertText("abc def", false, true)
+
+	// Backward one word
+	applyEmacsKeyEvent(buf, NewKeyEvent([]byte{0x1b, 'b'}))
+	if buf.cursorPosition != len("abc ") {
+		t.Errorf("Want %d, but got %d", len("abc "), buf.cursorPosition)
+	}
+
+	// Forward one word
+	applyEmacsKeyEvent(buf, NewKeyEvent([]byte{0x1b, 'f'}))
+	if buf.cursorPosition != len("abc def") {
+		t.Errorf("Want %d, but got %d", len("abc def"), buf.cursorPosition)
+	}
+}
+
 func applyEmacsKeyBind(buf *Buffer, key Key) {
+	applyEmacsKeyEvent(buf, KeyEvent{Key: key})
+}
+
+func applyEmacsKeyEvent(buf *Buffer, ev KeyEvent) {
 	for i := range emacsKeyBindings {
 		kb := emacsKeyBindings[i]
-		if kb.Key == key {
+		if kb.match(ev) {
 			kb.Fn(buf)
 		}
 	}
@@ -0,0 +1,102 @@
+package prompt
+
+import "sync"
+
+// event is delivered to the main loop of Prompt.
+// All state changes of a running Prompt go through it,
+// so that only the main loop touches the buffer and the renderer.
+type event struct {
+	kind    eventKind
+	input   []byte
+	keys    []KeyEvent
+	winSize *WinSize
+	err     error
+	fn      func() *Exec
+}
+
+type eventKind int
+
+const (
+	// eventInput carries bytes read from ConsoleParser.
+	eventInput eventKind = iota
+	// eventWinSize is sent when the terminal is resized.
+	eventWinSize
+	// eventExit stops the main loop with err.
+	eventExit
+	// eventRequest runs fn on the main loop and renders the prompt.
+	// If fn returns Exec, the input is submitted like Enter key.
+	eventRequest
+	// eventPrint prints input above the prompt.
+	eventPrint
+	// eventKeys carries keys decoded while the main loop waited for a cursor position report.
+	eventKeys
+)
+
+// eventQueue is an unbounded FIFO of events, so that pushing never blocks
+// even on the main loop, e.g. in a KeyBindFunc or a Completer.
+type eventQueue struct {
+	mu     sync.Mutex
+	events []event
+	// ready has a value while the queue isn't empty.
+	ready chan struct{}
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *eventQueue) push(ev event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.events = append(q.events, ev)
+	q.signal()
+}
+
+// pushFront puts evs back to the front of the queue in order.
+func (q *eventQueue) pushFront(evs ...event) {
+	if len(evs) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.events = append(append([]event{}, evs...), q.events...)
+	q.signal()
+}
+
+// pop removes the first event. It should be called after receiving from ready.
+func (q *eventQueue) pop() (event, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.events) == 0 {
+		return event{}, false
+	}
+	ev := q.events[0]
+	q.events = q.events[1:]
+	if len(q.events) > 0 {
+		q.signal()
+	}
+	return ev, true
+}
+
+// remove removes the events of kind and returns them in order.
+func (q *eventQueue) remove(kind eventKind) []event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var removed, kept []event
+	for _, ev := range q.events {
+		if ev.kind == kind {
+			removed = append(removed, ev)
+		} else {
+			kept = append(kept, ev)
+		}
+	}
+	q.events = kept
+	return removed
+}
+
+func (q *eventQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,143 @@
+package prompt
+
+import (
+	"math"
+	"sort"
+	"unicode"
+)
+
+const (
+	fuzzyScoreMatch        = 16
+	fuzzyScoreGapStart     = -3
+	fuzzyScoreGapExtension = -1
+	fuzzyBonusConsecutive  = 8
+	fuzzyBonusWordStart    = 8
+	fuzzyBonusCamelCase    = 7
+)
+
+// FuzzyMatch checks whether text fuzzy matches pattern like FilterFuzzy, and scores the match.
+// The score is higher when the matched runes are consecutive or at the start of words
+// and camelCase humps. indices holds the positions in runes of the matched runes in text.
+func FuzzyMatch(text, pattern string, ignoreCase bool) (score int, indices []int, ok bool) {
+	t, p := []rune(text), []rune(pattern)
+	n, m := len(t), len(p)
+	if m == 0 {
+		return 0, nil, true
+	}
+	if m > n {
+		return 0, nil, false
+	}
+
+	const none = math.MinInt32
+	// scores[i*n+j] is the best score when p[i] is matched with t[j],
+	// and from[i*n+j] is the position matched with p[i-1] then.
+	scores := make([]int, m*n)
+	from := make([]int, m*n)
+	for i := 0; i < m; i++ {
+		// The best score of p[i-1] matched before t[j-1], minus the penalty of the gap up to t[j].
+		gapped, gappedFrom := none, -1
+		for j := 0; j < n; j++ {
+			k := i*n + j
+			scores[k] = none
+			if i > 0 && j >= 2 {
+				if gapped != none {
+					gapped += fuzzyScoreGapExtension
+				}
+				if s := scores[k-n-2]; s != none && s+fuzzyScoreGapStart > gapped {
+					gapped, gappedFrom = s+fuzzyScoreGapStart, j-2
+				}
+			}
+			if !fuzzyRuneEqual(t[j], p[i], ignoreCase) {
+				continue
+			}
+			bonus := fuzzyMatchBonus(t, j)
+			if i == 0 {
+				scores[k], from[k] = fuzzyScoreMatch+bonus, -1
+				continue
+			}
+			if j > 0 && scores[k-n-1] != none {
+				scores[k], from[k] = scores[k-n-1]+fuzzyScoreMatch+bonus+fuzzyBonusConsecutive, j-1
+			}
+			if gapped != none && gapped+fuzzyScoreMatch+bonus > scores[k] {
+				scores[k], from[k] = gapped+fuzzyScoreMatch+bonus, gappedFrom
+			}
+		}
+	}
+
+	last := -1
+	for j := m - 1; j < n; j++ {
+		if s := scores[(m-1)*n+j]; s != none && (last < 0 || s > scores[(m-1)*n+last]) {
+			last = j
+		}
+	}
+	if last < 0 {
+		return 0, nil, false
+	}
+	indices = make([]int, m)
+	for i, j := m-1, last; i >= 0; i-- {
+		indices[i] = j
+		j = from[i*n+j]
+	}
+	return scores[(m-1)*n+last], indices, true
+}
+
+func fuzzyRuneEqual(a, b rune, ignoreCase bool) bool {
+	if ignoreCase {
+		return unicode.ToLower(a) == unicode.ToLower(b)
+	}
+	return a == b
+}
+
+// fuzzyMatchBonus returns the bonus for matching t[j].
+func fuzzyMatchBonus(t []rune, j int) int {
+	cur := t[j]
+	if !unicode.IsLetter(cur) && !unicode.IsDigit(cur) {
+		return 0
+	}
+	if j == 0 {
+		return fuzzyBonusWordStart
+	}
+	switch prev := t[j-1]; {
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+		return fuzzyBonusWordStart
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return fuzzyBonusCamelCase
+	case !unicode.IsDigit(prev) && unicode.IsDigit(cur):
+		return fuzzyBonusCamelCase
+	}
+	return 0
+}
+
+// FilterFuzzyScored checks whether the completion.Text fuzzy matches sub like FilterFuzzy,
+// and sorts the suggestions by the score of FuzzyMatch in descending order.
+// The completion menu highlights the matched runes when OptionSuggestionMatchStyle is given.
+func FilterFuzzyScored(completions []Suggest, sub string, ignoreCase bool) []Suggest {
+	if sub == "" {
+		return completions
+	}
+
+	ret := make([]Suggest, 0, len(completions))
+	scores := make([]int, 0, len(completions))
+	for i := range completions {
+		score, _, ok := FuzzyMatch(completions[i].Text, sub, ignoreCase)
+		if !ok {
+			continue
+		}
+		ret = append(ret, completions[i])
+		scores = append(scores, score)
+	}
+	sort.Stable(&scoredSuggestions{suggestions: ret, scores: scores})
+	return ret
+}
+
+type scoredSuggestions struct {
+	suggestions []Suggest
+	scores      []int
+}
+
+func (s *scoredSuggestions) Len() int           { return len(s.suggestions) }
+func (s *scoredSuggestions) Less(i, j int) bool { return s.scores[i] > s.scores[j] }
+func (s *scoredSuggestions) Swap(i, j int) {
+	s.suggestions[i], s.suggestions[j] = s.suggestions[j], s.suggestions[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
@@ -0,0 +1,128 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatchIndices(t *testing.T) {
+	scenarioTable := []struct {
+		text       string
+		pattern    string
+		ignoreCase bool
+		indices    []int
+		ok         bool
+	}{
+		{text: "git checkout", pattern: "gco", indices: []int{0, 4, 9}, ok: true},
+		{text: "git checkout", pattern: "chk", indices: []int{4, 5, 8}, ok: true},
+		{text: "GetWordBeforeCursor", pattern: "gwbc", ignoreCase: true, indices: []int{0, 3, 7, 13}, ok: true},
+		{text: "GetWordBeforeCursor", pattern: "gwbc"},
+		{text: "abcabc", pattern: "bc", indices: []int{1, 2}, ok: true},
+		{text: "foo_bar", pattern: "fb", indices: []int{0, 4}, ok: true},
+		{text: "こんにちは", pattern: "んち", indices: []int{1, 3}, ok: true},
+		{text: "abc", pattern: "abcd"},
+		{text: "abc", pattern: "", ok: true},
+	}
+	for _, s := range scenarioTable {
+		_, indices, ok := FuzzyMatch(s.text, s.pattern, s.ignoreCase)
+		if ok != s.ok {
+			t.Errorf("%s, %s: Should be %#v, but got %#v", s.text, s.pattern, s.ok, ok)
+		}
+		if !reflect.DeepEqual(indices, s.indices) {
+			t.Errorf("%s, %s: Should be %#v, but got %#v", s.text, s.pattern, s.indices, indices)
+		}
+	}
+}
+
+func TestFuzzyMatchScore(t *testing.T) {
+	scenarioTable := []struct {
+		scenario string
+		pattern  string
+		better   string
+		worse    string
+	}{
+		{scenario: "consecutive", pattern: "abc", better: "xabcx", worse: "xaxbxcx"},
+		{scenario: "word start", pattern: "gco", better: "git checkout", worse: "xgxcxo"},
+		{scenario: "camelCase", pattern: "fb", better: "fooBar", worse: "foobar"},
+		{scenario: "digit", pattern: "v2", better: "vim2", worse: "vim12"},
+	}
+	for _, s := range scenarioTable {
+		better, _, _ := FuzzyMatch(s.better, s.pattern, true)
+		worse, _, _ := FuzzyMatch(s.worse, s.pattern, true)
+		if better <= worse {
+			t.Errorf("%s: Should be higher than %d, but got %d", s.scenario, worse, better)
+		}
+	}
+}
+
+func TestFilterFuzzyScored(t *testing.T) {
+	list := []Suggest{
+		{Text: "gxxcxxxxxxxxxo"},
+		{Text: "status"},
+		{Text: "git checkout"},
+		{Text: "git commit"},
+	}
+	expected := []Suggest{
+		{Text: "git commit"},
+		{Text: "git checkout"},
+		{Text: "gxxcxxxxxxxxxo"},
+	}
+	if actual := FilterFuzzyScored(list, "gco", false); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+	if actual := FilterFuzzyScored(list, "", false); !reflect.DeepEqual(actual, list) {
+		t.Errorf("Should be %#v, but got %#v", list, actual)
+	}
+}
+
+func TestFormatMatches(t *testing.T) {
+	scenarioTable := []struct {
+		suggest  Suggest
+		matches  []int
+		width    int
+		expected []int
+	}{
+		{suggest: Suggest{Text: "abcdef"}, matches: []int{0, 2}, width: 8, expected: []int{1, 3}},
+		{suggest: Suggest{Text: "abcdefghij"}, matches: []int{1, 6, 8}, width: 10, expected: []int{2}},
+		{suggest: Suggest{Text: "ab\ncd"}, matches: []int{0}, width: 8, expected: nil},
+		{suggest: Suggest{Text: "abcdef"}, width: 8, expected: nil},
+	}
+	for _, s := range scenarioTable {
+		if actual := formatMatches(s.suggest, s.matches, s.width); !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+		}
+	}
+}
+
+func TestRenderSuggestionText(t *testing.T) {
+	r := &Render{
+		out:                  &mockWriter{},
+		suggestionMatchStyle: &Style{TextColor: Yellow, Attributes: []DisplayAttribute{DisplayUnderline}},
+	}
+	r.renderSuggestionText(" abc ", []int{1, 3}, White, Cyan, false)
+	expected := "\x1b[0;97;46m \x1b[0;4;93;46ma\x1b[0;97;46mb\x1b[0;4;93;46mc\x1b[0;97;46m "
+	if actual := string(r.out.(*mockWriter).buffer); actual != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+}
+
+func TestCompletionMatchesOf(t *testing.T) {
+	scenarioTable := []struct {
+		text     string
+		suggest  Suggest
+		expected []int
+	}{
+		{text: "git co", suggest: Suggest{Text: "checkout"}, expected: []int{0, 5}},
+		{text: "git co", suggest: Suggest{Text: "checkout", Display: "checkout (branch)"}, expected: []int{0, 5}},
+		{text: "git co", suggest: Suggest{Text: "status"}, expected: nil},
+		{text: "git ", suggest: Suggest{Text: "status"}, expected: nil},
+		{text: "git.co", suggest: Suggest{Text: "git.commit", Replace: &ReplaceRange{Start: -6}}, expected: []int{0, 1, 2, 3, 4, 5}},
+	}
+	c := NewCompletionManager(nil, 5)
+	for _, s := range scenarioTable {
+		d := &Document{Text: s.text, cursorPosition: len([]rune(s.text))}
+		if actual := c.matchesOf(d, s.suggest); !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+		}
+	}
+}
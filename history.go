@@ -1,16 +1,52 @@
 package prompt
 
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/c-bata/go-prompt/internal/debug"
+)
+
 // History stores the texts that are entered.
 type History struct {
 	histories []string
 	tmp       []string
 	selected  int
+	store     HistoryStore
+	loaded    bool
 }
 
 // Add to add text in history.
+// If a HistoryStore is set, the text is appended to it as well.
 func (h *History) Add(input string) {
+	if err := h.add(input); err != nil {
+		debug.Log("failed to append history: " + err.Error())
+	}
+}
+
+// add adds input like Add and returns the error of the HistoryStore.
+func (h *History) add(input string) error {
 	h.histories = append(h.histories, input)
 	h.Clear()
+	if h.store == nil {
+		return nil
+	}
+	return h.store.Append(input)
+}
+
+// load reads the entries from the HistoryStore once and puts them before the current entries.
+func (h *History) load() error {
+	if h.store == nil || h.loaded {
+		return nil
+	}
+	entries, err := h.store.Load()
+	if err != nil {
+		return err
+	}
+	h.loaded = true
+	h.histories = append(entries, h.histories...)
+	h.Clear()
+	return nil
 }
 
 // Clear to clear the history.
@@ -51,6 +87,28 @@ func (h *History) Newer(buf *Buffer) (new *Buffer, changed bool) {
 	return new, true
 }
 
+// Search looks for an entry which contains query from the entry at start toward older entries,
+// or toward newer entries if forward is true. It returns the index of the entry
+// and the position of query in the entry counted in runes.
+func (h *History) Search(query string, start int, forward bool) (index, pos int, ok bool) {
+	for i := start; 0 <= i && i < len(h.histories); {
+		entry := h.histories[i]
+		j := strings.LastIndex(entry, query)
+		if forward {
+			j = strings.Index(entry, query)
+		}
+		if j != -1 {
+			return i, utf8.RuneCountInString(entry[:j]), true
+		}
+		if forward {
+			i++
+		} else {
+			i--
+		}
+	}
+	return 0, 0, false
+}
+
 // NewHistory returns new history object.
 func NewHistory() *History {
 	return &History{
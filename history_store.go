@@ -0,0 +1,208 @@
+package prompt
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HistoryStore loads and saves the entries of History.
+type HistoryStore interface {
+	// Load returns the stored entries from the oldest one.
+	Load() ([]string, error)
+	// Append stores an entry added to History.
+	Append(entry string) error
+}
+
+// FileHistoryStore is a HistoryStore which saves entries into a file.
+// Each entry is written in a line, so line breaks and backslashes in it are escaped.
+// The file is locked while reading and writing so that concurrent sessions can share it.
+type FileHistoryStore struct {
+	path       string
+	maxEntries int
+	maxBytes   int64
+}
+
+// FileHistoryOption is the type to configure FileHistoryStore.
+type FileHistoryOption func(*FileHistoryStore)
+
+// FileHistoryMaxEntries limits the number of stored entries.
+// The oldest entries are removed when it is exceeded. 0 means no limit.
+func FileHistoryMaxEntries(n int) FileHistoryOption {
+	return func(s *FileHistoryStore) {
+		s.maxEntries = n
+	}
+}
+
+// FileHistoryMaxBytes limits the size of the file.
+// The oldest entries are removed when it is exceeded. 0 means no limit.
+func FileHistoryMaxBytes(n int64) FileHistoryOption {
+	return func(s *FileHistoryStore) {
+		s.maxBytes = n
+	}
+}
+
+// NewFileHistoryStore returns a FileHistoryStore which saves entries into the file at path.
+// The file is created when the first entry is appended.
+func NewFileHistoryStore(path string, opts ...FileHistoryOption) *FileHistoryStore {
+	s := &FileHistoryStore{path: path}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Load returns the entries in the file. It returns no entries if the file doesn't exist.
+func (s *FileHistoryStore) Load() ([]string, error) {
+	f, err := openLockedHistoryFile(s.path, os.O_RDONLY, false)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	defer unlockFile(f)
+
+	lines, err := readHistoryLines(f)
+	if err != nil {
+		return nil, err
+	}
+	lines = s.trim(lines)
+	entries := make([]string, len(lines))
+	for i := range lines {
+		entries[i] = unescapeHistoryEntry(lines[i])
+	}
+	return entries, nil
+}
+
+// Append writes entry at the end of the file with a single write.
+// When the file exceeds the limits, the oldest entries are removed.
+func (s *FileHistoryStore) Append(entry string) error {
+	f, err := openLockedHistoryFile(s.path, os.O_RDWR|os.O_APPEND|os.O_CREATE, true)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer unlockFile(f)
+
+	if _, err = f.Write([]byte(escapeHistoryEntry(entry) + "\n")); err != nil {
+		return err
+	}
+	if s.maxEntries <= 0 && s.maxBytes <= 0 {
+		return nil
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	lines, err := readHistoryLines(f)
+	if err != nil {
+		return err
+	}
+	trimmed := s.trim(lines)
+	if len(trimmed) == len(lines) {
+		return nil
+	}
+	// Replace the file with the trimmed one so that it's never left half-written.
+	// The lock of the old file is held until it's replaced, and the other sessions
+	// waiting for the lock open the new file again.
+	var buf bytes.Buffer
+	for _, l := range trimmed {
+		buf.WriteString(l)
+		buf.WriteByte('\n')
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(buf.Bytes()); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// openLockedHistoryFile opens the file at path with flag and locks it.
+// Append of another session may replace the file while waiting for the lock,
+// so it's opened again until the locked file is the one at path.
+func openLockedHistoryFile(path string, flag int, exclusive bool) (*os.File, error) {
+	for {
+		f, err := openHistoryFile(path, flag)
+		if err != nil {
+			return nil, err
+		}
+		if err = lockFile(f, exclusive); err != nil {
+			f.Close()
+			return nil, err
+		}
+		var locked, current os.FileInfo
+		if locked, err = f.Stat(); err == nil {
+			if current, err = os.Stat(path); err == nil && os.SameFile(locked, current) {
+				return f, nil
+			} else if os.IsNotExist(err) {
+				err = nil
+			}
+		}
+		unlockFile(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// trim removes the oldest lines exceeding the limits.
+func (s *FileHistoryStore) trim(lines []string) []string {
+	if s.maxEntries > 0 && len(lines) > s.maxEntries {
+		lines = lines[len(lines)-s.maxEntries:]
+	}
+	if s.maxBytes > 0 {
+		var size int64
+		for i := len(lines) - 1; i >= 0; i-- {
+			size += int64(len(lines[i]) + 1)
+			if size > s.maxBytes {
+				return lines[i+1:]
+			}
+		}
+	}
+	return lines
+}
+
+func readHistoryLines(r io.Reader) ([]string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, len(b)+1)
+	for sc.Scan() {
+		if l := sc.Text(); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines, sc.Err()
+}
+
+var (
+	historyEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+	historyUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r")
+)
+
+func escapeHistoryEntry(entry string) string {
+	return historyEscaper.Replace(entry)
+}
+
+func unescapeHistoryEntry(line string) string {
+	return historyUnescaper.Replace(line)
+}
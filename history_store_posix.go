@@ -0,0 +1,32 @@
+// +build !windows
+
+package prompt
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile locks f with flock(2). It blocks until the lock is acquired.
+func lockFile(f *os.File, exclusive bool) error {
+	how := unix.LOCK_SH
+	if exclusive {
+		how = unix.LOCK_EX
+	}
+	for {
+		err := unix.Flock(int(f.Fd()), how)
+		if err != unix.EINTR {
+			return err
+		}
+	}
+}
+
+// openHistoryFile opens the file at path with flag.
+func openHistoryFile(path string, flag int) (*os.File, error) {
+	return os.OpenFile(path, flag, 0600)
+}
+
+func unlockFile(f *os.File) error {
+	return unix.Flock(int(f.Fd()), unix.LOCK_UN)
+}
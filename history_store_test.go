@@ -0,0 +1,248 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestHistoryFile(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "go-prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "history")
+}
+
+func TestFileHistoryStore(t *testing.T) {
+	path := newTestHistoryFile(t)
+	s := NewFileHistoryStore(path)
+
+	entries, err := s.Load()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Should be empty without the file, but got %#v, %v", entries, err)
+	}
+
+	expected := []string{"echo 1", "for i in 1 2\ndo echo $i\ndone", `printf 'a\nb\\'`, "crlf\r\n"}
+	for _, e := range expected {
+		if err = s.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	actual, err := NewFileHistoryStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+
+	// Each entry is written in a line.
+	b, _ := ioutil.ReadFile(path)
+	if lines := strings.Count(string(b), "\n"); lines != len(expected) {
+		t.Errorf("Should be %#v, but got %#v", len(expected), lines)
+	}
+}
+
+func TestFileHistoryStoreLimits(t *testing.T) {
+	scenarioTable := []struct {
+		name     string
+		opts     []FileHistoryOption
+		expected []string
+	}{
+		{
+			name:     "max entries",
+			opts:     []FileHistoryOption{FileHistoryMaxEntries(2)},
+			expected: []string{"ccc", "dddd"},
+		},
+		{
+			name:     "max bytes",
+			opts:     []FileHistoryOption{FileHistoryMaxBytes(10)},
+			expected: []string{"ccc", "dddd"},
+		},
+		{
+			name:     "both",
+			opts:     []FileHistoryOption{FileHistoryMaxEntries(3), FileHistoryMaxBytes(6)},
+			expected: []string{"dddd"},
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			path := newTestHistoryFile(t)
+			store := NewFileHistoryStore(path, s.opts...)
+			for _, e := range []string{"a", "bb", "ccc", "dddd"} {
+				if err := store.Append(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			actual, err := NewFileHistoryStore(path).Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual, s.expected) {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFileHistoryStoreConcurrentAppend(t *testing.T) {
+	path := newTestHistoryFile(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := NewFileHistoryStore(path, FileHistoryMaxEntries(50))
+			for j := 0; j < 20; j++ {
+				if err := s.Append("multi\nline entry"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	// The readers never see the file being trimmed.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			entries, err := NewFileHistoryStore(path).Load()
+			if err != nil {
+				t.Error(err)
+			}
+			if len(entries) > 50 {
+				t.Errorf("Should be at most %#v, but got %#v", 50, len(entries))
+			}
+			for _, e := range entries {
+				if e != "multi\nline entry" {
+					t.Errorf("Should not be corrupted, but got %#v", e)
+				}
+			}
+		}
+	}()
+	wg.Wait()
+
+	entries, err := NewFileHistoryStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 50 {
+		t.Errorf("Should be %#v, but got %#v", 50, len(entries))
+	}
+	for _, e := range entries {
+		if e != "multi\nline entry" {
+			t.Fatalf("Should not be corrupted, but got %#v", e)
+		}
+	}
+	// The temporary files are renamed to the history file.
+	if files, _ := ioutil.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Errorf("Should be %#v, but got %#v", 1, len(files))
+	}
+}
+
+func TestHistoryWithStore(t *testing.T) {
+	path := newTestHistoryFile(t)
+	if err := NewFileHistoryStore(path).Append("stored"); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHistory()
+	h.histories = []string{"option"}
+	h.store = NewFileHistoryStore(path)
+	if err := h.load(); err != nil {
+		t.Fatal(err)
+	}
+	h.Add("new")
+	// The store is loaded only once.
+	if err := h.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"stored", "option", "new"}
+	if !reflect.DeepEqual(h.histories, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, h.histories)
+	}
+	expected = []string{"stored", "new"}
+	if actual, _ := h.store.Load(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+}
+
+func TestPromptInputHistoryLoadError(t *testing.T) {
+	// A directory can't be loaded as a history file.
+	dir := filepath.Dir(newTestHistoryFile(t))
+	p := newMockPrompt(dummyExecutor, newMockParser("hello", "\n"), OptionHistoryStore(NewFileHistoryStore(dir)))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	in := p.Input()
+	os.Stderr = stderr
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+
+	// The prompt starts without the stored history.
+	if in != "hello" {
+		t.Errorf("Should be %#v, but got %#v", "hello", in)
+	}
+	if !strings.HasPrefix(string(out), "prompt: failed to load history: ") {
+		t.Errorf("Should report the error, but got %#v", string(out))
+	}
+}
+
+// failingHistoryStore fails to append entries.
+type failingHistoryStore struct{}
+
+func (failingHistoryStore) Load() ([]string, error) { return nil, nil }
+func (failingHistoryStore) Append(string) error     { return errors.New("disk full") }
+
+func TestPromptInputHistoryAppendError(t *testing.T) {
+	p := newMockPrompt(dummyExecutor, newMockParser("hello", "\n"), OptionHistoryStore(failingHistoryStore{}))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	in := p.Input()
+	os.Stderr = stderr
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+
+	if in != "hello" {
+		t.Errorf("Should be %#v, but got %#v", "hello", in)
+	}
+	if expected := "prompt: failed to append history: disk full\n"; string(out) != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, string(out))
+	}
+	// The entry is added to the history in memory anyway.
+	if expected := []string{"hello"}; !reflect.DeepEqual(p.history.histories, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, p.history.histories)
+	}
+}
+
+func TestPromptRunContextHistoryErrorHandler(t *testing.T) {
+	var errs []error
+	p := newMockPrompt(dummyExecutor, newMockParser("a\n", "b\n"),
+		OptionHistoryStore(failingHistoryStore{}),
+		OptionHistoryErrorHandler(func(err error) { errs = append(errs, err) }),
+		OptionSetExitCheckerOnInput(func(in string, breakline bool) bool { return in == "b" && breakline }))
+	if err := p.RunContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 2 || errs[0].Error() != "disk full" || errs[1].Error() != "disk full" {
+		t.Errorf("Should be %#v, but got %#v", []string{"disk full", "disk full"}, errs)
+	}
+}
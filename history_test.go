@@ -60,3 +60,30 @@ func TestHistoryOlder(t *testing.T) {
 		t.Errorf("Should be %#v, but got %#v", "echo 1", buf2.Text())
 	}
 }
+
+func TestHistorySearchEntries(t *testing.T) {
+	h := NewHistory()
+	for _, s := range []string{"echo 日本語", "ls", "echo foo"} {
+		h.Add(s)
+	}
+	scenarioTable := []struct {
+		query   string
+		start   int
+		forward bool
+		index   int
+		pos     int
+		ok      bool
+	}{
+		{query: "echo", start: 2, index: 2, pos: 0, ok: true},
+		{query: "echo", start: 1, index: 0, pos: 0, ok: true},
+		{query: "語", start: 2, index: 0, pos: 7, ok: true},
+		{query: "ls", start: 0, forward: true, index: 1, pos: 0, ok: true},
+		{query: "bar", start: 2, ok: false},
+	}
+	for _, s := range scenarioTable {
+		index, pos, ok := h.Search(s.query, s.start, s.forward)
+		if index != s.index || pos != s.pos || ok != s.ok {
+			t.Errorf("Should be %#v, but got %#v", []interface{}{s.index, s.pos, s.ok}, []interface{}{index, pos, ok})
+		}
+	}
+}
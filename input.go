@@ -1,6 +1,12 @@
 package prompt
 
-import "bytes"
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrReadInterrupted is returned by Read of BlockingConsoleParser when Interrupt wakes it up.
+var ErrReadInterrupted = errors.New("prompt: read interrupted")
 
 // WinSize represents the width and height of terminal.
 type WinSize struct {
@@ -20,6 +26,15 @@ type ConsoleParser interface {
 	Read() ([]byte, error)
 }
 
+// BlockingConsoleParser is a ConsoleParser whose Read blocks until input is available.
+// Prompt waits on it without polling and calls Interrupt when it stops reading.
+type BlockingConsoleParser interface {
+	ConsoleParser
+	// Interrupt wakes up a blocking Read, which then returns ErrReadInterrupted.
+	// If no Read is blocking, the next one returns immediately.
+	Interrupt() error
+}
+
 // GetKey returns Key correspond to input byte codes.
 func GetKey(b []byte) Key {
 	for _, k := range ASCIISequences {
@@ -114,7 +129,7 @@ var ASCIISequences = []*ASCIICode{
 	{Key: F9, ASCIICode: []byte{0x1b, 0x5b, 0x32, 0x30, 0x7e}},
 	{Key: F10, ASCIICode: []byte{0x1b, 0x5b, 0x32, 0x31, 0x7e}},
 	{Key: F11, ASCIICode: []byte{0x1b, 0x5b, 0x32, 0x32, 0x7e}},
-	{Key: F12, ASCIICode: []byte{0x1b, 0x5b, 0x32, 0x34, 0x7e, 0x8}},
+	{Key: F12, ASCIICode: []byte{0x1b, 0x5b, 0x32, 0x34, 0x7e}},
 	{Key: F13, ASCIICode: []byte{0x1b, 0x5b, 0x25, 0x7e}},
 	{Key: F14, ASCIICode: []byte{0x1b, 0x5b, 0x26, 0x7e}},
 	{Key: F15, ASCIICode: []byte{0x1b, 0x5b, 0x28, 0x7e}},
@@ -3,6 +3,7 @@
 package prompt
 
 import (
+	"io"
 	"syscall"
 
 	"github.com/c-bata/go-prompt/internal/term"
@@ -15,41 +16,77 @@ const maxReadBytes = 1024
 type PosixParser struct {
 	fd          int
 	origTermios syscall.Termios
+	// wakeUp is a pipe to interrupt select(2) in Read. wakeUp[0] is the read end.
+	wakeUp [2]int
 }
 
 // Setup should be called before starting input
 func (t *PosixParser) Setup() error {
-	// Set NonBlocking mode because if syscall.Read block this goroutine, it cannot receive data from stopCh.
-	if err := syscall.SetNonblock(t.fd, true); err != nil {
-		return err
-	}
-	if err := term.SetRaw(t.fd); err != nil {
-		return err
-	}
-	return nil
+	return term.SetRaw(t.fd)
 }
 
 // TearDown should be called after stopping input
 func (t *PosixParser) TearDown() error {
-	if err := syscall.SetNonblock(t.fd, false); err != nil {
-		return err
-	}
-	if err := term.Restore(); err != nil {
-		return err
-	}
-	return nil
+	return term.Restore()
 }
 
 // Read returns byte array.
+// It blocks until the terminal has input or Interrupt is called.
 func (t *PosixParser) Read() ([]byte, error) {
+	var fds unix.FdSet
+	for {
+		fds.Zero()
+		fds.Set(t.fd)
+		fds.Set(t.wakeUp[0])
+		nfd := t.fd
+		if t.wakeUp[0] > nfd {
+			nfd = t.wakeUp[0]
+		}
+		// select(2) is used instead of poll(2) because poll(2) does not support /dev/tty on macOS.
+		if _, err := unix.Select(nfd+1, &fds, nil, nil, nil); err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return []byte{}, err
+		}
+		break
+	}
+
+	if fds.IsSet(t.wakeUp[0]) {
+		t.drainWakeUp()
+		return []byte{}, ErrReadInterrupted
+	}
+
 	buf := make([]byte, maxReadBytes)
 	n, err := syscall.Read(t.fd, buf)
 	if err != nil {
 		return []byte{}, err
 	}
+	if n == 0 {
+		return []byte{}, io.EOF
+	}
 	return buf[:n], nil
 }
 
+// Interrupt wakes up a blocking Read.
+func (t *PosixParser) Interrupt() error {
+	_, err := syscall.Write(t.wakeUp[1], []byte{0})
+	if err == syscall.EAGAIN {
+		// The pipe is full, so Read will be woken up anyway.
+		return nil
+	}
+	return err
+}
+
+func (t *PosixParser) drainWakeUp() {
+	buf := make([]byte, 64)
+	for {
+		if n, err := syscall.Read(t.wakeUp[0], buf); n <= 0 || err != nil {
+			return
+		}
+	}
+}
+
 // GetWinSize returns WinSize object to represent width and height of terminal.
 func (t *PosixParser) GetWinSize() *WinSize {
 	ws, err := unix.IoctlGetWinsize(t.fd, unix.TIOCGWINSZ)
@@ -62,7 +99,7 @@ func (t *PosixParser) GetWinSize() *WinSize {
 	}
 }
 
-var _ ConsoleParser = &PosixParser{}
+var _ BlockingConsoleParser = &PosixParser{}
 
 // NewStandardInputParser returns ConsoleParser object to read from stdin.
 func NewStandardInputParser() *PosixParser {
@@ -71,7 +108,18 @@ func NewStandardInputParser() *PosixParser {
 		panic(err)
 	}
 
+	var wakeUp [2]int
+	if err = syscall.Pipe(wakeUp[:]); err != nil {
+		panic(err)
+	}
+	for _, fd := range wakeUp {
+		if err = syscall.SetNonblock(fd, true); err != nil {
+			panic(err)
+		}
+	}
+
 	return &PosixParser{
-		fd: in,
+		fd:     in,
+		wakeUp: wakeUp,
 	}
 }
@@ -0,0 +1,53 @@
+// +build !windows
+
+package prompt
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestPosixParserRead(t *testing.T) {
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fds[0])
+	defer syscall.Close(fds[1])
+
+	var wakeUp [2]int
+	if err := syscall.Pipe(wakeUp[:]); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(wakeUp[0])
+	defer syscall.Close(wakeUp[1])
+	for _, fd := range wakeUp {
+		if err := syscall.SetNonblock(fd, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := &PosixParser{fd: fds[0], wakeUp: wakeUp}
+
+	if _, err := syscall.Write(fds[1], []byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "abc" {
+		t.Errorf("Should be %#v, but got %#v", "abc", string(b))
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := p.Read()
+		done <- err
+	}()
+	if err = p.Interrupt(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; err != ErrReadInterrupted {
+		t.Errorf("Should be %v, but got %v", ErrReadInterrupted, err)
+	}
+}
@@ -3,23 +3,19 @@
 package prompt
 
 import (
-	"errors"
-	"syscall"
 	"unicode/utf8"
-	"unsafe"
 
 	tty "github.com/mattn/go-tty"
+	"golang.org/x/sys/windows"
 )
 
 const maxReadBytes = 1024
 
-var kernel32 = syscall.NewLazyDLL("kernel32.dll")
-
-var procGetNumberOfConsoleInputEvents = kernel32.NewProc("GetNumberOfConsoleInputEvents")
-
 // WindowsParser is a ConsoleParser implementation for Win32 console.
 type WindowsParser struct {
 	tty *tty.TTY
+	// wakeUp is an event object to interrupt WaitForMultipleObjects in Read.
+	wakeUp windows.Handle
 }
 
 // Setup should be called before starting input
@@ -38,14 +34,17 @@ func (p *WindowsParser) TearDown() error {
 }
 
 // Read returns byte array.
+// It blocks until the console has input or Interrupt is called.
 func (p *WindowsParser) Read() ([]byte, error) {
-	var ev uint32
-	r0, _, err := procGetNumberOfConsoleInputEvents.Call(p.tty.Input().Fd(), uintptr(unsafe.Pointer(&ev)))
-	if r0 == 0 {
-		return nil, err
-	}
-	if ev == 0 {
-		return nil, errors.New("EAGAIN")
+	if !p.tty.Buffered() {
+		handles := []windows.Handle{windows.Handle(p.tty.Input().Fd()), p.wakeUp}
+		e, err := windows.WaitForMultipleObjects(handles, false, windows.INFINITE)
+		if err != nil {
+			return nil, err
+		}
+		if e == windows.WAIT_OBJECT_0+1 {
+			return nil, ErrReadInterrupted
+		}
 	}
 
 	r, err := p.tty.ReadRune()
@@ -65,6 +64,11 @@ func (p *WindowsParser) Read() ([]byte, error) {
 	return buf[:n], nil
 }
 
+// Interrupt wakes up a blocking Read.
+func (p *WindowsParser) Interrupt() error {
+	return windows.SetEvent(p.wakeUp)
+}
+
 // GetWinSize returns WinSize object to represent width and height of terminal.
 func (p *WindowsParser) GetWinSize() *WinSize {
 	w, h, err := p.tty.Size()
@@ -77,7 +81,16 @@ func (p *WindowsParser) GetWinSize() *WinSize {
 	}
 }
 
+var _ BlockingConsoleParser = &WindowsParser{}
+
 // NewStandardInputParser returns ConsoleParser object to read from stdin.
 func NewStandardInputParser() *WindowsParser {
-	return &WindowsParser{}
+	// An auto-reset event goes back to non-signaled state when WaitForMultipleObjects returns by it.
+	e, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		panic(err)
+	}
+	return &WindowsParser{
+		wakeUp: e,
+	}
 }
@@ -4,9 +4,25 @@ package prompt
 type KeyBindFunc func(*Buffer)
 
 // KeyBind represents which key should do what operation.
+// To bind a key with modifiers like Ctrl+Left or Alt+b, set Modifiers with Key or Rune.
 type KeyBind struct {
 	Key Key
 	Fn  KeyBindFunc
+	// Rune is a character to bind instead of Key.
+	Rune rune
+	// Modifiers are the modifier keys which must be pressed with Key or Rune.
+	Modifiers Modifier
+}
+
+// match returns whether the key binding should handle e.
+func (kb KeyBind) match(e KeyEvent) bool {
+	if kb.Rune != 0 {
+		return e.Rune == kb.Rune && e.Modifiers == kb.Modifiers
+	}
+	if kb.Modifiers != 0 {
+		return e.Key == kb.Key && e.Modifiers == kb.Modifiers
+	}
+	return e.key() == kb.Key
 }
 
 // ASCIICodeBind represents which []byte should do what operation
@@ -23,6 +39,8 @@ const (
 	CommonKeyBind KeyBindMode = "common"
 	// EmacsKeyBind is a mode to use emacs-like keyboard shortcut
 	EmacsKeyBind KeyBindMode = "emacs"
+	// ViKeyBind is a mode to use vi-like insert, normal and visual modes
+	ViKeyBind KeyBindMode = "vi"
 )
 
 var commonKeyBindings = []KeyBind{
@@ -56,4 +74,27 @@ var commonKeyBindings = []KeyBind{
 		Key: Left,
 		Fn:  GoLeftChar,
 	},
+	// Ctrl+Right allow: Forward one word
+	{
+		Key:       Right,
+		Modifiers: ModCtrl,
+		Fn:        GoRightWord,
+	},
+	// Ctrl+Left allow: Backward one word
+	{
+		Key:       Left,
+		Modifiers: ModCtrl,
+		Fn:        GoLeftWord,
+	},
+	// Undo
+	{
+		Key: ControlZ,
+		Fn:  Undo,
+	},
+	// Redo
+	{
+		Rune:      'z',
+		Modifiers: ModAlt,
+		Fn:        Redo,
+	},
 }
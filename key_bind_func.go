@@ -46,3 +46,13 @@ func GoRightWord(buf *Buffer) {
 func GoLeftWord(buf *Buffer) {
 	buf.CursorLeft(len([]rune(buf.Document().TextBeforeCursor())) - buf.Document().FindStartOfPreviousWordWithSpace())
 }
+
+// Undo Revert the last edit
+func Undo(buf *Buffer) {
+	buf.Undo()
+}
+
+// Redo Reapply the last edit reverted by Undo
+func Redo(buf *Buffer) {
+	buf.Redo()
+}
@@ -0,0 +1,168 @@
+package prompt
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Modifier is a set of modifier keys pressed with a key.
+type Modifier uint8
+
+const (
+	// ModShift represents Shift key.
+	ModShift Modifier = 1 << iota
+	// ModAlt represents Alt key. Terminals send it as ESC prefix or an xterm modifier parameter.
+	ModAlt
+	// ModCtrl represents Control key.
+	ModCtrl
+	// ModMeta represents Meta key. Only xterm modifier parameters can express it.
+	ModMeta
+)
+
+// KeyEvent is a key press with modifier keys.
+// Ctrl+Right is expressed as KeyEvent{Key: Right, Modifiers: ModCtrl}
+// and Alt+f is expressed as KeyEvent{Key: NotDefined, Rune: 'f', Modifiers: ModAlt}.
+type KeyEvent struct {
+	// Key is the key pressed with modifiers. It is NotDefined for a character key.
+	Key Key
+	// Rune is the character of a character key.
+	Rune rune
+	// Modifiers are the modifier keys pressed with Key or Rune.
+	Modifiers Modifier
+	// ASCIICode is the byte sequence sent by the terminal.
+	// For BracketedPaste, it is the pasted text without the markers.
+	ASCIICode []byte
+}
+
+// modifiedKey is a pair of a key and modifiers which has its own Key constant.
+type modifiedKey struct {
+	key       Key
+	modifiers Modifier
+}
+
+// modifiedKeys maps Key constants which contain modifiers to their base keys.
+var modifiedKeys = map[Key]modifiedKey{
+	ControlLeft:   {Left, ModCtrl},
+	ControlRight:  {Right, ModCtrl},
+	ControlUp:     {Up, ModCtrl},
+	ControlDown:   {Down, ModCtrl},
+	ShiftLeft:     {Left, ModShift},
+	ShiftRight:    {Right, ModShift},
+	ShiftUp:       {Up, ModShift},
+	ShiftDown:     {Down, ModShift},
+	ShiftDelete:   {Delete, ModShift},
+	ControlDelete: {Delete, ModCtrl},
+}
+
+// csiFinalKeys maps the final bytes of "CSI 1 ; <modifiers> <final>" to keys.
+var csiFinalKeys = map[byte]Key{
+	'A': Up,
+	'B': Down,
+	'C': Right,
+	'D': Left,
+	'F': End,
+	'H': Home,
+	'P': F1,
+	'Q': F2,
+	'R': F3,
+	'S': F4,
+}
+
+// csiTildeKeys maps the first parameter of "CSI <number> ; <modifiers> ~" to keys.
+var csiTildeKeys = map[int]Key{
+	1:  Home,
+	2:  Insert,
+	3:  Delete,
+	4:  End,
+	5:  PageUp,
+	6:  PageDown,
+	7:  Home,
+	8:  End,
+	15: F5,
+	17: F6,
+	18: F7,
+	19: F8,
+	20: F9,
+	21: F10,
+	23: F11,
+	24: F12,
+}
+
+// NewKeyEvent returns KeyEvent correspond to a byte sequence of a single key.
+func NewKeyEvent(b []byte) KeyEvent {
+	e := KeyEvent{Key: GetKey(b), ASCIICode: b}
+	if m, ok := modifiedKeys[e.Key]; ok {
+		e.Key, e.Modifiers = m.key, m.modifiers
+		return e
+	}
+	if e.Key != NotDefined || len(b) == 0 {
+		return e
+	}
+
+	if b[0] != 0x1b {
+		if r, n := utf8.DecodeRune(b); r != utf8.RuneError && n == len(b) {
+			e.Rune = r
+		}
+		return e
+	}
+	if key, mod, ok := parseXtermModifiers(b); ok {
+		e.Key, e.Modifiers = key, mod
+		return e
+	}
+	if len(b) >= 2 && b[1] != '[' && b[1] != 'O' && b[1] != 0x1b {
+		// Meta prefixed key like ESC f for Alt+f.
+		inner := NewKeyEvent(b[1:])
+		e.Key, e.Rune, e.Modifiers = inner.Key, inner.Rune, inner.Modifiers|ModAlt
+	}
+	return e
+}
+
+// parseXtermModifiers parses "CSI 1 ; <modifiers> <final>" and "CSI <number> ; <modifiers> ~".
+func parseXtermModifiers(b []byte) (key Key, mod Modifier, ok bool) {
+	if len(b) < 6 || b[1] != '[' {
+		return NotDefined, 0, false
+	}
+	final := b[len(b)-1]
+	var params []int
+	start := 2
+	for i := 2; i < len(b); i++ {
+		if b[i] != ';' && i != len(b)-1 {
+			continue
+		}
+		n, err := strconv.Atoi(string(b[start:i]))
+		if err != nil {
+			return NotDefined, 0, false
+		}
+		params = append(params, n)
+		start = i + 1
+	}
+	if len(params) != 2 || params[1] < 2 {
+		return NotDefined, 0, false
+	}
+
+	if final == '~' {
+		key, ok = csiTildeKeys[params[0]]
+	} else if params[0] == 1 {
+		key, ok = csiFinalKeys[final]
+	}
+	// The parameter is 1 + (1 for Shift) + (2 for Alt) + (4 for Control) + (8 for Meta).
+	return key, Modifier(params[1] - 1), ok
+}
+
+// key returns Key which the key bindings without modifiers and the internal handling use.
+// Modified keys which don't have their own Key constant become NotDefined.
+func (e KeyEvent) key() Key {
+	if e.Modifiers == 0 {
+		return e.Key
+	}
+	for k, m := range modifiedKeys {
+		if m.key == e.Key && m.modifiers == e.Modifiers {
+			return k
+		}
+	}
+	if k := GetKey(e.ASCIICode); k != NotDefined {
+		// e.g. F13 is sent as Shift+F1 by xterm.
+		return k
+	}
+	return NotDefined
+}
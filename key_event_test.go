@@ -0,0 +1,148 @@
+package prompt
+
+import (
+	"testing"
+)
+
+func TestNewKeyEvent(t *testing.T) {
+	scenarioTable := []struct {
+		name      string
+		input     []byte
+		key       Key
+		r         rune
+		modifiers Modifier
+	}{
+		{
+			name:  "character",
+			input: []byte("a"),
+			key:   NotDefined,
+			r:     'a',
+		},
+		{
+			name:  "multi-byte character",
+			input: []byte("日"),
+			key:   NotDefined,
+			r:     '日',
+		},
+		{
+			name:  "arrow key",
+			input: []byte{0x1b, 0x5b, 0x43},
+			key:   Right,
+		},
+		{
+			name:      "legacy modified key",
+			input:     []byte{0x1b, 0x5b, 0x31, 0x3b, 0x35, 0x43},
+			key:       Right,
+			modifiers: ModCtrl,
+		},
+		{
+			name:      "xterm modifier parameter",
+			input:     []byte("\x1b[1;3D"),
+			key:       Left,
+			modifiers: ModAlt,
+		},
+		{
+			name:      "xterm modifier parameter with tilde",
+			input:     []byte("\x1b[5;6~"),
+			key:       PageUp,
+			modifiers: ModShift | ModCtrl,
+		},
+		{
+			name:      "meta prefixed character",
+			input:     []byte{0x1b, 'f'},
+			key:       NotDefined,
+			r:         'f',
+			modifiers: ModAlt,
+		},
+		{
+			name:      "meta prefixed control key",
+			input:     []byte{0x1b, 0x7f},
+			key:       Backspace,
+			modifiers: ModAlt,
+		},
+		{
+			name:  "unknown escape sequence",
+			input: []byte("\x1b[99~"),
+			key:   NotDefined,
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			e := NewKeyEvent(s.input)
+			if e.Key != s.key {
+				t.Errorf("Should be %s, but got %s", s.key, e.Key)
+			}
+			if e.Rune != s.r {
+				t.Errorf("Should be %#v, but got %#v", s.r, e.Rune)
+			}
+			if e.Modifiers != s.modifiers {
+				t.Errorf("Should be %#v, but got %#v", s.modifiers, e.Modifiers)
+			}
+		})
+	}
+}
+
+func TestKeyBindMatch(t *testing.T) {
+	ctrlRight := NewKeyEvent([]byte{0x1b, 0x5b, 0x31, 0x3b, 0x35, 0x43})
+	altRight := NewKeyEvent([]byte("\x1b[1;3C"))
+	altF := NewKeyEvent([]byte{0x1b, 'f'})
+
+	scenarioTable := []struct {
+		name     string
+		bind     KeyBind
+		event    KeyEvent
+		expected bool
+	}{
+		{
+			name:     "key with modifiers",
+			bind:     KeyBind{Key: Right, Modifiers: ModCtrl},
+			event:    ctrlRight,
+			expected: true,
+		},
+		{
+			name:     "legacy modified key",
+			bind:     KeyBind{Key: ControlRight},
+			event:    ctrlRight,
+			expected: true,
+		},
+		{
+			name:     "key without modifiers",
+			bind:     KeyBind{Key: Right},
+			event:    ctrlRight,
+			expected: false,
+		},
+		{
+			name:     "different modifiers",
+			bind:     KeyBind{Key: Right, Modifiers: ModCtrl},
+			event:    altRight,
+			expected: false,
+		},
+		{
+			name:     "rune with modifiers",
+			bind:     KeyBind{Rune: 'f', Modifiers: ModAlt},
+			event:    altF,
+			expected: true,
+		},
+		{
+			name:     "rune without modifiers",
+			bind:     KeyBind{Rune: 'f'},
+			event:    altF,
+			expected: false,
+		},
+		{
+			name:     "escape key",
+			bind:     KeyBind{Key: Escape},
+			event:    altF,
+			expected: false,
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			if actual := s.bind.match(s.event); actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package prompt
+
+// killRingMax is the number of entries which KillRing keeps.
+const killRingMax = 60
+
+type killRingAction int
+
+const (
+	killRingNone killRingAction = iota
+	killRingKill
+	killRingYank
+)
+
+// KillRing stores the texts cut by key bindings like Ctrl+W and Ctrl+K
+// so that they can be pasted (yanked) later. Each Prompt has its own KillRing.
+type KillRing struct {
+	entries   []string // The newest entry is the last one.
+	yankIndex int
+
+	// The actions of the previous key and the current key.
+	// Kills by consecutive keys are merged into the latest entry.
+	lastAction killRingAction
+	action     killRingAction
+	// The range of the text inserted by the last yank, which yank-pop replaces.
+	yankStart int
+	yankEnd   int
+}
+
+// NewKillRing returns an empty KillRing.
+func NewKillRing() *KillRing {
+	return &KillRing{}
+}
+
+// nextKey is called before handling each key to detect consecutive kills and yanks.
+func (r *KillRing) nextKey() {
+	r.lastAction = r.action
+	r.action = killRingNone
+}
+
+// Push adds text as a new entry.
+func (r *KillRing) Push(text string) {
+	if text == "" {
+		return
+	}
+	r.entries = append(r.entries, text)
+	if len(r.entries) > killRingMax {
+		r.entries = r.entries[len(r.entries)-killRingMax:]
+	}
+	r.yankIndex = len(r.entries) - 1
+}
+
+// Kill adds text cut after the cursor. If the previous key also killed text,
+// text is appended to the latest entry instead of adding a new one.
+func (r *KillRing) Kill(text string) {
+	r.kill(text, false)
+}
+
+// KillBackward adds text cut before the cursor. If the previous key also killed text,
+// text is prepended to the latest entry instead of adding a new one.
+func (r *KillRing) KillBackward(text string) {
+	r.kill(text, true)
+}
+
+func (r *KillRing) kill(text string, backward bool) {
+	if text == "" {
+		return
+	}
+	continuing := r.lastAction == killRingKill || r.action == killRingKill
+	r.action = killRingKill
+	if !continuing || len(r.entries) == 0 {
+		r.Push(text)
+		return
+	}
+	latest := len(r.entries) - 1
+	if backward {
+		r.entries[latest] = text + r.entries[latest]
+	} else {
+		r.entries[latest] += text
+	}
+	r.yankIndex = latest
+}
+
+// Latest returns the newest entry.
+func (r *KillRing) Latest() (string, bool) {
+	if len(r.entries) == 0 {
+		return "", false
+	}
+	return r.entries[len(r.entries)-1], true
+}
+
+// Entries returns all entries from the newest one.
+func (r *KillRing) Entries() []string {
+	entries := make([]string, len(r.entries))
+	for i := range r.entries {
+		entries[i] = r.entries[len(r.entries)-1-i]
+	}
+	return entries
+}
+
+func (r *KillRing) yank(buf *Buffer) {
+	text, ok := r.Latest()
+	if !ok {
+		return
+	}
+	r.yankIndex = len(r.entries) - 1
+	r.yankStart = buf.cursorPosition
+	buf.InsertText(text, false, true)
+	r.yankEnd = buf.cursorPosition
+	r.action = killRingYank
+}
+
+func (r *KillRing) yankPop(buf *Buffer) {
+	if r.lastAction != killRingYank || len(r.entries) == 0 || buf.cursorPosition != r.yankEnd {
+		return
+	}
+	r.yankIndex--
+	if r.yankIndex < 0 {
+		r.yankIndex = len(r.entries) - 1
+	}
+	text := r.entries[r.yankIndex]
+	rs := []rune(buf.Text())
+	buf.saveUndoState(false)
+	buf.setDocument(&Document{
+		Text:           string(rs[:r.yankStart]) + text + string(rs[r.yankEnd:]),
+		cursorPosition: r.yankStart + len([]rune(text)),
+	})
+	r.yankEnd = buf.cursorPosition
+	r.action = killRingYank
+}
@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKillRing(t *testing.T) {
+	r := NewKillRing()
+	r.nextKey()
+	r.Kill("foo")
+	r.nextKey()
+	r.Kill(" bar")
+	r.nextKey()
+	r.KillBackward("baz ")
+	r.nextKey()
+	r.nextKey()
+	r.Kill("qux")
+	r.Push("quux")
+
+	expected := []string{"quux", "qux", "baz foo bar"}
+	if actual := r.Entries(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+	if actual, _ := r.Latest(); actual != "quux" {
+		t.Errorf("Should be %#v, but got %#v", "quux", actual)
+	}
+}
+
+func TestKillRingMax(t *testing.T) {
+	r := NewKillRing()
+	for i := 0; i < killRingMax+10; i++ {
+		r.Push("x")
+	}
+	if len(r.Entries()) != killRingMax {
+		t.Errorf("Should be %#v, but got %#v", killRingMax, len(r.Entries()))
+	}
+}
+
+func TestPromptYank(t *testing.T) {
+	p := newMockPrompt(nil, newMockParser())
+	p.buf.InsertText("foo bar baz", false, true)
+
+	feed := func(b ...byte) {
+		if _, err := p.feed(NewKeyEvent(b)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	feed(0x17) // Ctrl+W
+	feed(0x17) // Ctrl+W
+	feed(0x2)  // Ctrl+B
+	feed(0x17) // Ctrl+W
+	feed(0x19) // Ctrl+Y
+	if expected := "foo "; p.buf.Text() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.buf.Text())
+	}
+	feed(0x1b, 'y') // Alt+Y
+	if expected := "bar baz "; p.buf.Text() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.buf.Text())
+	}
+	feed(0x1b, 'y') // Alt+Y
+	if expected := "foo "; p.buf.Text() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.buf.Text())
+	}
+
+	// Alt+Y does nothing unless the previous key yanked.
+	feed(0x1)       // Ctrl+A
+	feed(0x1b, 'y') // Alt+Y
+	if expected := "foo "; p.buf.Text() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.buf.Text())
+	}
+}
@@ -0,0 +1,183 @@
+package prompt
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Style represents colors and display attributes of a text.
+// DefaultColor keeps the color of the input text.
+type Style struct {
+	TextColor  Color
+	BGColor    Color
+	Attributes []DisplayAttribute
+}
+
+// StyledText is a text displayed with Style.
+type StyledText struct {
+	Text  string
+	Style Style
+}
+
+// Token is a span of the input text to be displayed with Style.
+// Start and End are positions in runes, and End is exclusive.
+type Token struct {
+	Start int
+	End   int
+	Style Style
+}
+
+// Lexer splits the input text into tokens for syntax highlighting.
+type Lexer interface {
+	// Lex returns the tokens of d.Text. The text which no token covers is displayed as is,
+	// and a token is styled over the tokens before it when they overlap.
+	Lex(d Document) []Token
+}
+
+// LexerFunc is a function which implements Lexer.
+type LexerFunc func(d Document) []Token
+
+// Lex calls f(d).
+func (f LexerFunc) Lex(d Document) []Token {
+	return f(d)
+}
+
+// RegexpRule styles the texts which Pattern matches.
+type RegexpRule struct {
+	Pattern *regexp.Regexp
+	Style   Style
+}
+
+// RegexpLexer is a Lexer which styles the texts matched by the rules.
+// When matches overlap, the rule given first wins.
+type RegexpLexer struct {
+	rules []RegexpRule
+}
+
+// NewRegexpLexer returns a RegexpLexer.
+func NewRegexpLexer(rules ...RegexpRule) *RegexpLexer {
+	return &RegexpLexer{rules: rules}
+}
+
+// Lex implements Lexer.
+func (l *RegexpLexer) Lex(d Document) []Token {
+	var tokens []Token
+	claimed := make([]bool, len(d.Text))
+	for _, rule := range l.rules {
+	matches:
+		for _, m := range rule.Pattern.FindAllStringIndex(d.Text, -1) {
+			if m[0] == m[1] {
+				continue
+			}
+			for i := m[0]; i < m[1]; i++ {
+				if claimed[i] {
+					continue matches
+				}
+			}
+			for i := m[0]; i < m[1]; i++ {
+				claimed[i] = true
+			}
+			start := utf8.RuneCountInString(d.Text[:m[0]])
+			tokens = append(tokens, Token{
+				Start: start,
+				End:   start + utf8.RuneCountInString(d.Text[m[0]:m[1]]),
+				Style: rule.Style,
+			})
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Start < tokens[j].Start })
+	return tokens
+}
+
+// ShellWordsLexer is a Lexer which splits the input like a POSIX shell
+// and styles the command name, options, quoted strings and operators.
+type ShellWordsLexer struct {
+	Command  Style
+	Option   Style
+	String   Style
+	Operator Style
+	Comment  Style
+	// Error styles an unterminated quote.
+	Error Style
+}
+
+// NewShellWordsLexer returns a ShellWordsLexer with the default styles.
+func NewShellWordsLexer() *ShellWordsLexer {
+	return &ShellWordsLexer{
+		Command:  Style{TextColor: Green, Attributes: []DisplayAttribute{DisplayBold}},
+		Option:   Style{TextColor: Turquoise},
+		String:   Style{TextColor: Yellow},
+		Operator: Style{TextColor: Fuchsia},
+		Comment:  Style{TextColor: DarkGray},
+		Error:    Style{TextColor: White, BGColor: DarkRed},
+	}
+}
+
+const shellOperatorRunes = "|&;<>()"
+
+// Lex implements Lexer.
+func (l *ShellWordsLexer) Lex(d Document) []Token {
+	var tokens []Token
+	rs := []rune(d.Text)
+	commandExpected := true
+	for i := 0; i < len(rs); {
+		switch r := rs[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '#':
+			tokens = append(tokens, Token{Start: i, End: len(rs), Style: l.Comment})
+			i = len(rs)
+		case strings.ContainsRune(shellOperatorRunes, r):
+			start := i
+			for i < len(rs) && strings.ContainsRune(shellOperatorRunes, rs[i]) {
+				i++
+			}
+			tokens = append(tokens, Token{Start: start, End: i, Style: l.Operator})
+			commandExpected = true
+		default:
+			start, n := i, len(tokens)
+			tokens, i = l.lexWord(tokens, rs, i)
+			if rs[start] == '-' || commandExpected {
+				word := Token{Start: start, End: i, Style: l.Command}
+				if rs[start] == '-' {
+					word.Style = l.Option
+				}
+				// The word comes before the quoted strings in it so that they are styled over the word.
+				tokens = append(tokens[:n], append([]Token{word}, tokens[n:]...)...)
+			}
+			commandExpected = false
+		}
+	}
+	return tokens
+}
+
+// lexWord skips a word starting at rs[i], appending the tokens of quoted strings in it.
+func (l *ShellWordsLexer) lexWord(tokens []Token, rs []rune, i int) ([]Token, int) {
+	for i < len(rs) && !unicode.IsSpace(rs[i]) && !strings.ContainsRune(shellOperatorRunes, rs[i]) {
+		switch rs[i] {
+		case '\\':
+			i += 2
+		case '\'', '"':
+			quote, start := rs[i], i
+			for i++; i < len(rs) && rs[i] != quote; i++ {
+				if quote == '"' && rs[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(rs) {
+				return append(tokens, Token{Start: start, End: len(rs), Style: l.Error}), len(rs)
+			}
+			i++
+			tokens = append(tokens, Token{Start: start, End: i, Style: l.String})
+		default:
+			i++
+		}
+	}
+	if i > len(rs) {
+		i = len(rs)
+	}
+	return tokens, i
+}
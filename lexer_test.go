@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestRegexpLexer(t *testing.T) {
+	keyword := Style{TextColor: Blue}
+	number := Style{TextColor: Green}
+	l := NewRegexpLexer(
+		RegexpRule{Pattern: regexp.MustCompile(`\b(select|from)\b`), Style: keyword},
+		RegexpRule{Pattern: regexp.MustCompile(`[a-z0-9]+`), Style: number},
+	)
+	expected := []Token{
+		{Start: 0, End: 6, Style: keyword},
+		{Start: 8, End: 9, Style: number},
+		{Start: 11, End: 15, Style: keyword},
+		{Start: 16, End: 17, Style: number},
+	}
+	if actual := l.Lex(Document{Text: "select あ1, from t"}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+}
+
+func TestShellWordsLexer(t *testing.T) {
+	l := NewShellWordsLexer()
+	scenarioTable := []struct {
+		text     string
+		expected []Token
+	}{
+		{
+			text: `ls -la "my dir"`,
+			expected: []Token{
+				{Start: 0, End: 2, Style: l.Command},
+				{Start: 3, End: 6, Style: l.Option},
+				{Start: 7, End: 15, Style: l.String},
+			},
+		},
+		{
+			text: `cat a\ b|grep 'x' # find x`,
+			expected: []Token{
+				{Start: 0, End: 3, Style: l.Command},
+				{Start: 8, End: 9, Style: l.Operator},
+				{Start: 9, End: 13, Style: l.Command},
+				{Start: 14, End: 17, Style: l.String},
+				{Start: 18, End: 26, Style: l.Comment},
+			},
+		},
+		{
+			text: `"echo" --msg="hi`,
+			expected: []Token{
+				{Start: 0, End: 6, Style: l.Command},
+				{Start: 0, End: 6, Style: l.String},
+				{Start: 7, End: 16, Style: l.Option},
+				{Start: 13, End: 16, Style: l.Error},
+			},
+		},
+	}
+	for _, s := range scenarioTable {
+		if actual := l.Lex(Document{Text: s.text}); !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+		}
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	w := &mockWriter{}
+	r := &Render{out: w, livePrefixCallback: func() (string, bool) { return "", false }, col: 80, inputTextColor: Blue, inputBGColor: DefaultColor}
+	r.renderText("ab c", []Token{
+		{Start: 0, End: 2, Style: Style{TextColor: Red}},
+		{Start: 1, End: 2, Style: Style{Attributes: []DisplayAttribute{DisplayBold}}},
+	})
+	expected := "\x1b[0;91;49ma\x1b[0;1;91;49mb\x1b[0;94;49m c\x1b[0;39;49m"
+	if actual := string(w.buffer); actual != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+}
@@ -0,0 +1,22 @@
+package prompt
+
+// feedMultiline handles the keys which behave differently in multi-line mode.
+// Enter inserts a newline instead of submitting the input while isComplete reports it's incomplete,
+// and Alt+Enter always inserts one. Up and Down move the cursor between the lines.
+func (p *Prompt) feedMultiline(ev KeyEvent, key Key, completing bool) bool {
+	d := p.buf.Document()
+	isEnter := ev.Key == Enter || ev.Key == ControlJ || ev.Key == ControlM
+	switch {
+	case isEnter && ev.Modifiers == ModAlt:
+		p.buf.NewLine(true)
+	case isEnter && ev.Modifiers == 0 && !p.isComplete(*d):
+		p.buf.NewLine(true)
+	case (key == Up || key == ControlP) && !completing && d.CursorPositionRow() > 0:
+		p.buf.CursorUp(1)
+	case (key == Down || key == ControlN) && !completing && !d.OnLastLine():
+		p.buf.CursorDown(1)
+	default:
+		return false
+	}
+	return true
+}
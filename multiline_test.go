@@ -0,0 +1,126 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMultilineFeed(t *testing.T) {
+	// The input is complete unless brackets are left open.
+	isComplete := func(d Document) bool {
+		return strings.Count(d.Text, "(") <= strings.Count(d.Text, ")")
+	}
+	scenarioTable := []struct {
+		name           string
+		keys           []string
+		expectedText   string
+		expectedCursor int
+		expectedExec   string
+	}{
+		{
+			name:           "enter inserts a newline while incomplete",
+			keys:           []string{"f", "(", "\r"},
+			expectedText:   "f(\n",
+			expectedCursor: 3,
+		},
+		{
+			name:         "enter submits the complete input",
+			keys:         []string{"f", "(", "\r", ")", "\r"},
+			expectedExec: "f(\n)",
+		},
+		{
+			name:           "alt+enter always inserts a newline",
+			keys:           []string{"f", "\x1b\r"},
+			expectedText:   "f\n",
+			expectedCursor: 2,
+		},
+		{
+			name:           "newline keeps the indentation",
+			keys:           []string{" ", "(", "\r"},
+			expectedText:   " (\n ",
+			expectedCursor: 4,
+		},
+		{
+			name:           "up and down move between the lines",
+			keys:           []string{"a", "b", "(", "\r", "c", "\x1b[A"},
+			expectedText:   "ab(\nc",
+			expectedCursor: 1,
+		},
+		{
+			name:           "down moves to the next line",
+			keys:           []string{"a", "b", "(", "\r", "c", "\x1b[A", "\x1b[B"},
+			expectedText:   "ab(\nc",
+			expectedCursor: 5,
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newMockPrompt(nil, newMockParser(), OptionMultiline(isComplete))
+			p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 80})
+			var exec *Exec
+			for _, k := range s.keys {
+				exec, _ = p.feed(NewKeyEvent([]byte(k)))
+			}
+			if s.expectedExec != "" {
+				if exec == nil || exec.input != s.expectedExec {
+					t.Errorf("Should be %#v, but got %#v", s.expectedExec, exec)
+				}
+				return
+			}
+			if exec != nil {
+				t.Errorf("Should be %#v, but got %#v", nil, exec)
+			}
+			if actual := p.buf.Text(); actual != s.expectedText {
+				t.Errorf("Should be %#v, but got %#v", s.expectedText, actual)
+			}
+			if actual := p.buf.cursorPosition; actual != s.expectedCursor {
+				t.Errorf("Should be %#v, but got %#v", s.expectedCursor, actual)
+			}
+		})
+	}
+}
+
+func TestRenderInputPosition(t *testing.T) {
+	scenarioTable := []struct {
+		prefix   string
+		text     string
+		expected int
+	}{
+		{prefix: "> ", text: "abc", expected: 5},
+		{prefix: "> ", text: "ab\ncd", expected: 14},
+		{prefix: "> ", text: "12345678\nx", expected: 13},
+		{prefix: "> ", text: "日本\n語", expected: 14},
+		{prefix: "> ", text: "123456789012345\n", expected: 22},
+		{prefix: "", text: "\n\n", expected: 20},
+	}
+	for _, s := range scenarioTable {
+		r := &Render{
+			prefix:             s.prefix,
+			livePrefixCallback: func() (string, bool) { return "", false },
+			col:                10,
+		}
+		if actual := r.inputPosition(s.text); actual != s.expected {
+			t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+		}
+	}
+}
+
+func TestRenderContinuationPrefix(t *testing.T) {
+	w := &mockWriter{}
+	r := &Render{
+		out:                w,
+		prefix:             ">>> ",
+		continuationPrefix: "... ",
+		livePrefixCallback: func() (string, bool) { return "", false },
+		col:                80,
+	}
+	cursor, lineWidth := r.renderText("if x:\n  y", nil)
+	if cursor != 87 || lineWidth != 7 {
+		t.Errorf("Should be %#v, but got %#v", []int{87, 7}, []int{cursor, lineWidth})
+	}
+	expected := "\x1b[0;39;49mif x:\x1b[0;39;49m\x1b[K\n\x1b[0;39;49m... \x1b[0;39;49m\x1b[0;39;49m  y\x1b[0;39;49m"
+	if actual := string(w.buffer); actual != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+}
@@ -1,5 +1,9 @@
 package prompt
 
+import (
+	"time"
+)
+
 // Option is the type to replace default parameters.
 // prompt.New accepts any number of options (this is functional option pattern).
 type Option func(prompt *Prompt) error
@@ -109,6 +113,22 @@ func OptionPreviewSuggestionBGColor(x Color) Option {
 	}
 }
 
+// OptionAutoSuggestionTextColor to change a text color of the text suggested by AutoSuggester
+func OptionAutoSuggestionTextColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.autoSuggestionTextColor = x
+		return nil
+	}
+}
+
+// OptionAutoSuggestionBGColor to change a background color of the text suggested by AutoSuggester
+func OptionAutoSuggestionBGColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.autoSuggestionBGColor = x
+		return nil
+	}
+}
+
 // OptionSuggestionTextColor to change a text color in drop down suggestions.
 func OptionSuggestionTextColor(x Color) Option {
 	return func(p *Prompt) error {
@@ -141,6 +161,16 @@ func OptionSelectedSuggestionBGColor(x Color) Option {
 	}
 }
 
+// OptionSuggestionMatchStyle to highlight the characters in drop down suggestions which fuzzy match
+// the text before the cursor they replace, like FilterFuzzyScored. DefaultColor keeps the color of the suggestion.
+// They aren't highlighted by default.
+func OptionSuggestionMatchStyle(x Style) Option {
+	return func(p *Prompt) error {
+		p.renderer.suggestionMatchStyle = &x
+		return nil
+	}
+}
+
 // OptionDescriptionTextColor to change a background color of description text in drop down suggestions.
 func OptionDescriptionTextColor(x Color) Option {
 	return func(p *Prompt) error {
@@ -206,6 +236,162 @@ func OptionHistory(x []string) Option {
 	}
 }
 
+// OptionHistoryStore to set a HistoryStore which persists the history.
+// The stored entries are loaded when the prompt starts and each entry added to the history is appended to it.
+func OptionHistoryStore(x HistoryStore) Option {
+	return func(p *Prompt) error {
+		p.history.store = x
+		return nil
+	}
+}
+
+// OptionHistoryErrorHandler to set a function called with the error when the HistoryStore fails to append an entry.
+// It's called after the terminal leaves raw mode. Run and Input report the error to os.Stderr without it.
+func OptionHistoryErrorHandler(fn func(error)) Option {
+	return func(p *Prompt) error {
+		p.historyErrorHandler = fn
+		return nil
+	}
+}
+
+// OptionMultiline enables multi-line input. Enter inserts a newline while isComplete returns false,
+// and Alt+Enter always inserts one.
+func OptionMultiline(isComplete func(Document) bool) Option {
+	return func(p *Prompt) error {
+		p.isComplete = isComplete
+		return nil
+	}
+}
+
+// OptionContinuationPrefix to set the prefix of the lines after the first one in multi-line input.
+// By default, they are indented as wide as the prefix.
+func OptionContinuationPrefix(x string) Option {
+	return func(p *Prompt) error {
+		p.renderer.continuationPrefix = x
+		return nil
+	}
+}
+
+// OptionValidator to set a Validator which checks the input when Enter is pressed.
+// The input isn't submitted while it returns an error.
+func OptionValidator(x Validator) Option {
+	return func(p *Prompt) error {
+		p.validator = x
+		return nil
+	}
+}
+
+// OptionValidateWhileTyping to run the Validator after each key, not only when Enter is pressed.
+func OptionValidateWhileTyping(x bool) Option {
+	return func(p *Prompt) error {
+		p.validateWhileTyping = x
+		return nil
+	}
+}
+
+// OptionValidationErrorTextColor to change a text color of the validation error.
+func OptionValidationErrorTextColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.validationErrorTextColor = x
+		return nil
+	}
+}
+
+// OptionValidationErrorBGColor to change a background color of the validation error.
+func OptionValidationErrorBGColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.validationErrorBGColor = x
+		return nil
+	}
+}
+
+// OptionRefreshInterval to redraw the prompt periodically, e.g. for a clock in the live prefix.
+func OptionRefreshInterval(d time.Duration) Option {
+	return func(p *Prompt) error {
+		p.refreshInterval = d
+		return nil
+	}
+}
+
+// OptionRightPrefix to display texts flush-right in the first row of the input like RPROMPT of zsh.
+// They are hidden while the input would overlap them. DefaultColor in their styles is replaced by the prefix colors.
+func OptionRightPrefix(fn func(Document) []StyledText) Option {
+	return func(p *Prompt) error {
+		p.renderer.rightPrefix = fn
+		return nil
+	}
+}
+
+// OptionBottomToolbar to display a toolbar below the input and the completion menu.
+// fn returns the texts to be displayed in it from the current input.
+// It can read the other state by Prompt.SelectedSuggestion and Prompt.ViMode.
+func OptionBottomToolbar(fn func(Document) []StyledText) Option {
+	return func(p *Prompt) error {
+		p.renderer.bottomToolbar = fn
+		return nil
+	}
+}
+
+// OptionBottomToolbarTextColor to change a text color of the bottom toolbar.
+func OptionBottomToolbarTextColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.bottomToolbarTextColor = x
+		return nil
+	}
+}
+
+// OptionBottomToolbarBGColor to change a background color of the bottom toolbar.
+func OptionBottomToolbarBGColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.bottomToolbarBGColor = x
+		return nil
+	}
+}
+
+// OptionAsyncCompleter to get the suggestions by AsyncCompleter instead of Completer.
+// It's called after the input is left unchanged for delay, and "Loading..." is displayed until it returns
+// if it takes a while.
+func OptionAsyncCompleter(x AsyncCompleter, delay time.Duration) Option {
+	return func(p *Prompt) error {
+		p.completion.async = &asyncCompletion{
+			completer:    x,
+			delay:        delay,
+			loadingDelay: asyncLoadingDelay,
+			post: func(fn func()) {
+				p.events.push(event{kind: eventRequest, fn: func() *Exec {
+					fn()
+					return nil
+				}})
+			},
+		}
+		return nil
+	}
+}
+
+// OptionLexer to set a Lexer which highlights the input text.
+func OptionLexer(x Lexer) Option {
+	return func(p *Prompt) error {
+		p.renderer.lexer = x
+		return nil
+	}
+}
+
+// OptionAutoSuggester to set an AutoSuggester which suggests the text following the input.
+func OptionAutoSuggester(x AutoSuggester) Option {
+	return func(p *Prompt) error {
+		p.autoSuggester = x
+		return nil
+	}
+}
+
+// OptionHistoryAutoSuggestion to suggest the rest of the newest history entry which starts with the input.
+func OptionHistoryAutoSuggestion() Option {
+	return func(p *Prompt) error {
+		p.autoSuggester = p.history
+		return nil
+	}
+}
+
 // OptionSwitchKeyBindMode set a key bind mode.
 func OptionSwitchKeyBindMode(m KeyBindMode) Option {
 	return func(p *Prompt) error {
@@ -214,6 +400,15 @@ func OptionSwitchKeyBindMode(m KeyBindMode) Option {
 	}
 }
 
+// OptionCompleteCommonPrefix makes Tab insert the longest common prefix of the suggestions like bash.
+// The suggestion is inserted directly when it's only one, and the menu is opened when there is nothing to insert.
+func OptionCompleteCommonPrefix() Option {
+	return func(p *Prompt) error {
+		p.completeCommonPrefix = true
+		return nil
+	}
+}
+
 // OptionCompletionOnDown allows for Down arrow key to trigger completion.
 func OptionCompletionOnDown() Option {
 	return func(p *Prompt) error {
@@ -222,6 +417,15 @@ func OptionCompletionOnDown() Option {
 	}
 }
 
+// OptionCompletionLayout to change the layout of the completion menu.
+// CompletionGrid is suitable for many short suggestions such as file names.
+func OptionCompletionLayout(x CompletionLayout) Option {
+	return func(p *Prompt) error {
+		p.completion.layout = x
+		return nil
+	}
+}
+
 // SwitchKeyBindMode to set a key bind mode.
 // Deprecated: Please use OptionSwitchKeyBindMode.
 var SwitchKeyBindMode = OptionSwitchKeyBindMode
@@ -266,13 +470,29 @@ func OptionSetExitCheckerOnInput(fn ExitChecker) Option {
 	}
 }
 
+// OptionPasteHandler to set a function which sanitizes or rejects the text pasted in bracketed paste mode.
+func OptionPasteHandler(fn PasteHandler) Option {
+	return func(p *Prompt) error {
+		p.pasteHandler = fn
+		return nil
+	}
+}
+
+// OptionViModeIndicator to set a function which returns a string displayed before the prefix
+// for each mode of ViKeyBind like "[N] " for normal mode.
+func OptionViModeIndicator(fn func(ViMode) string) Option {
+	return func(p *Prompt) error {
+		p.vi.indicator = fn
+		return nil
+	}
+}
+
 // New returns a Prompt with powerful auto-completion.
 func New(executor Executor, completer Completer, opts ...Option) *Prompt {
 	defaultWriter := NewStdoutWriter()
 	registerConsoleWriter(defaultWriter)
 
 	pt := &Prompt{
-		in: NewStandardInputParser(),
 		renderer: &Render{
 			prefix:                       "> ",
 			out:                          defaultWriter,
@@ -283,6 +503,12 @@ func New(executor Executor, completer Completer, opts ...Option) *Prompt {
 			inputBGColor:                 DefaultColor,
 			previewSuggestionTextColor:   Green,
 			previewSuggestionBGColor:     DefaultColor,
+			autoSuggestionTextColor:      DarkGray,
+			autoSuggestionBGColor:        DefaultColor,
+			validationErrorTextColor:     Red,
+			validationErrorBGColor:       DefaultColor,
+			bottomToolbarTextColor:       Black,
+			bottomToolbarBGColor:         LightGray,
 			suggestionTextColor:          White,
 			suggestionBGColor:            Cyan,
 			selectedSuggestionTextColor:  Black,
@@ -294,17 +520,24 @@ func New(executor Executor, completer Completer, opts ...Option) *Prompt {
 			scrollbarThumbColor:          DarkGray,
 			scrollbarBGColor:             Cyan,
 		},
-		buf:         NewBuffer(),
+		events:      newEventQueue(),
+		invalidated: make(chan struct{}, 1),
+		killRing:    NewKillRing(),
 		executor:    executor,
 		history:     NewHistory(),
 		completion:  NewCompletionManager(completer, 6),
 		keyBindMode: EmacsKeyBind, // All the above assume that bash is running in the default Emacs setting
 	}
+	pt.setBuffer(NewBuffer())
 
 	for _, opt := range opts {
 		if err := opt(pt); err != nil {
 			panic(err)
 		}
 	}
+	if pt.in == nil {
+		// Open a terminal only when OptionParser is not given.
+		pt.in = NewStandardInputParser()
+	}
 	return pt
 }
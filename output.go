@@ -41,6 +41,20 @@ const (
 	DisplayDefaultFont
 )
 
+// CursorShape represents the shape of the cursor.
+type CursorShape int
+
+const (
+	// CursorDefault is the shape configured in the terminal.
+	CursorDefault CursorShape = iota
+	// CursorBlock is a block cursor.
+	CursorBlock
+	// CursorUnderline is an underline cursor.
+	CursorUnderline
+	// CursorBar is a vertical bar cursor.
+	CursorBar
+)
+
 // Color represents color on terminal.
 type Color int
 
@@ -162,6 +162,34 @@ func (w *VT100Writer) UnSaveCursor() {
 	w.WriteRaw([]byte{0x1b, '[', 'u'})
 }
 
+// SetCursorShape changes the shape of the cursor (DECSCUSR).
+func (w *VT100Writer) SetCursorShape(shape CursorShape) {
+	var p byte
+	switch shape {
+	case CursorBlock:
+		p = '2'
+	case CursorUnderline:
+		p = '4'
+	case CursorBar:
+		p = '6'
+	default:
+		p = '0'
+	}
+	w.WriteRaw([]byte{0x1b, '[', p, ' ', 'q'})
+}
+
+/* Bracketed paste */
+
+// EnableBracketedPaste makes the terminal wrap pasted text with ESC[200~ and ESC[201~.
+func (w *VT100Writer) EnableBracketedPaste() {
+	w.WriteRaw([]byte{0x1b, '[', '?', '2', '0', '0', '4', 'h'})
+}
+
+// DisableBracketedPaste disables bracketed paste mode.
+func (w *VT100Writer) DisableBracketedPaste() {
+	w.WriteRaw([]byte{0x1b, '[', '?', '2', '0', '0', '4', 'l'})
+}
+
 /* Scrolling */
 
 // ScrollDown scrolls display down one line.
@@ -0,0 +1,87 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/c-bata/go-prompt/internal/debug"
+)
+
+// Write prints b above the prompt and redraws the prompt below it. It is safe to call from any goroutine.
+// While the prompt is reading input, only complete lines are printed and the text after the last newline
+// is kept until a newline is written. Otherwise, e.g. the Executor is running or the prompt has returned,
+// b is written to the writer as it is, following the kept text.
+func (p *Prompt) Write(b []byte) (int, error) {
+	p.printMu.Lock()
+	defer p.printMu.Unlock()
+	p.printBuf = append(p.printBuf, b...)
+	if !p.readingInput() {
+		return len(b), p.writePrintBuf()
+	}
+	if i := bytes.LastIndexByte(p.printBuf, '\n'); i != -1 {
+		p.events.push(event{kind: eventPrint, input: append([]byte{}, p.printBuf[:i+1]...)})
+		p.printBuf = append([]byte{}, p.printBuf[i+1:]...)
+	}
+	return len(b), nil
+}
+
+// Printf formats according to a format specifier and prints the result above the prompt like Write.
+func (p *Prompt) Printf(format string, a ...interface{}) (int, error) {
+	return fmt.Fprintf(p, format, a...)
+}
+
+// flushPrints writes the text which Write hasn't printed yet to the writer as it is.
+// It's called when the prompt stops reading input.
+func (p *Prompt) flushPrints() {
+	p.printMu.Lock()
+	defer p.printMu.Unlock()
+	var text []byte
+	for _, ev := range p.events.remove(eventPrint) {
+		text = append(text, ev.input...)
+	}
+	p.printBuf = append(text, p.printBuf...)
+	debug.AssertNoError(p.writePrintBuf())
+}
+
+// writePrintBuf writes the text kept by Write. printMu must be held.
+func (p *Prompt) writePrintBuf() error {
+	if len(p.printBuf) == 0 {
+		return nil
+	}
+	p.outMu.Lock()
+	defer p.outMu.Unlock()
+	p.renderer.out.WriteRaw(p.printBuf)
+	p.printBuf = nil
+	return p.renderer.out.Flush()
+}
+
+// readingInput returns whether the main loop is reading input.
+func (p *Prompt) readingInput() bool {
+	p.stopInputMu.Lock()
+	defer p.stopInputMu.Unlock()
+	return p.stopInputCh != nil
+}
+
+// request queues fn to run on the main loop without waiting for it, so that it can be called
+// on the main loop as well, e.g. in a KeyBindFunc or a Completer. The requests run in the order of the calls.
+// It returns false without queuing fn if the prompt is not reading input.
+// If fn returns Exec, the main loop submits the input.
+func (p *Prompt) request(fn func() *Exec) bool {
+	if !p.readingInput() {
+		return false
+	}
+	p.events.push(event{kind: eventRequest, fn: fn})
+	return true
+}
+
+// printAbove erases the prompt and writes text, which ends with a newline, from the beginning of the prompt.
+// Render redraws the prompt after it.
+func (r *Render) printAbove(text string) {
+	if r.col != 0 {
+		r.clear(r.previousCursor)
+	}
+	r.previousCursor = 0
+	r.forgetScreenRow()
+	r.out.WriteRawStr(text)
+	debug.AssertNoError(r.out.Flush())
+}
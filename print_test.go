@@ -0,0 +1,139 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingWriter keeps all flushed output.
+type recordingWriter struct {
+	VT100Writer
+	flushed []byte
+}
+
+func (w *recordingWriter) Flush() error {
+	w.flushed = append(w.flushed, w.buffer...)
+	w.buffer = []byte{}
+	return nil
+}
+
+func TestPromptPrintf(t *testing.T) {
+	w := &recordingWriter{}
+	m := newMockParser()
+	p := newMockPrompt(nil, m, OptionWriter(w))
+
+	done := startMockPrompt(p, m)
+	sendMockInput(m, "ab")
+	if n, err := p.Printf("log %d\n", 1); n != 6 || err != nil {
+		t.Errorf("Should be %#v, but got %#v", []interface{}{6, nil}, []interface{}{n, err})
+	}
+	m.input <- []byte("\n")
+	if in := <-done; in != "ab" {
+		t.Errorf("Should be %#v, but got %#v", "ab", in)
+	}
+
+	out := string(w.flushed)
+	i := strings.Index(out, "log 1\n")
+	if i == -1 {
+		t.Fatalf("Should contain %#v, but got %#v", "log 1\n", out)
+	}
+	// The prompt is redrawn below the printed text.
+	if !strings.Contains(out[i:], "> ") || !strings.Contains(out[i:], "ab") {
+		t.Errorf("Should redraw the prompt after %#v, but got %#v", "log 1\n", out[i:])
+	}
+}
+
+func TestPromptRequestNotRunning(t *testing.T) {
+	p := newMockPrompt(nil, newMockParser())
+	ran := false
+	if p.request(func() *Exec {
+		ran = true
+		return nil
+	}) || ran {
+		t.Errorf("Should be %#v, but got %#v", false, ran)
+	}
+}
+
+func TestPromptWritePartialLines(t *testing.T) {
+	w := &recordingWriter{}
+	m := newMockParser()
+	p := newMockPrompt(nil, m, OptionWriter(w))
+
+	done := startMockPrompt(p, m)
+	for _, text := range []string{"lo", "g 1\nlo", "g 2"} {
+		if n, err := p.Write([]byte(text)); n != len(text) || err != nil {
+			t.Errorf("Should be %#v, but got %#v", []interface{}{len(text), nil}, []interface{}{n, err})
+		}
+	}
+	m.input <- []byte("\n")
+	<-done
+
+	out := string(w.flushed)
+	if !strings.Contains(out, "log 1\n") {
+		t.Errorf("Should contain %#v, but got %#v", "log 1\n", out)
+	}
+	if strings.Contains(out, "lo\n") {
+		t.Errorf("Should keep the incomplete line, but got %#v", out)
+	}
+	// The incomplete line is written when the prompt stops reading input.
+	if !strings.HasSuffix(out[:strings.LastIndex(out, "\x1b[?2004l")], "log 2") {
+		t.Errorf("Should end with %#v, but got %#v", "log 2", out)
+	}
+	if len(p.printBuf) != 0 {
+		t.Errorf("Should be empty, but got %#v", string(p.printBuf))
+	}
+}
+
+func TestPromptWriteNotRunning(t *testing.T) {
+	w := &recordingWriter{}
+	p := newMockPrompt(nil, newMockParser(), OptionWriter(w))
+	if _, err := p.Write([]byte("log\n")); err != nil {
+		t.Fatal(err)
+	}
+	// It's written to the writer as it is.
+	if string(w.flushed) != "log\n" {
+		t.Errorf("Should be %#v, but got %#v", "log\n", string(w.flushed))
+	}
+}
+
+func TestPromptPrintfNotRunning(t *testing.T) {
+	w := &recordingWriter{}
+	p := newMockPrompt(nil, newMockParser(), OptionWriter(w))
+	if _, err := p.Printf("connected"); err != nil {
+		t.Fatal(err)
+	}
+	if string(w.flushed) != "connected" {
+		t.Errorf("Should be %#v, but got %#v", "connected", string(w.flushed))
+	}
+}
+
+func TestPromptRequestOnMainLoop(t *testing.T) {
+	w := &recordingWriter{}
+	m := newMockParser()
+	var p *Prompt
+	p = New(nil, func(d Document) []Suggest {
+		if d.Text != "" {
+			p.Printf("completing %s\n", d.Text)
+		}
+		return nil
+	}, OptionParser(m), OptionWriter(w), OptionAddKeyBind(KeyBind{
+		Key: ControlA,
+		Fn:  func(*Buffer) { p.Accept() },
+	}))
+
+	done := startMockPrompt(p, m)
+	sendMockInput(m, "a")
+	m.input <- []byte{0x1}
+	select {
+	case in := <-done:
+		if in != "a" {
+			t.Errorf("Should be %#v, but got %#v", "a", in)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Should not block the main loop")
+	}
+	if out := string(w.flushed); !strings.Contains(out, "completing a\n") {
+		t.Errorf("Should contain %#v, but got %#v", "completing a\n", out)
+	}
+}
@@ -2,12 +2,30 @@ package prompt
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/c-bata/go-prompt/internal/debug"
 )
 
+var (
+	// ErrInterrupted is returned when the prompt is stopped by SIGINT or SIGQUIT.
+	ErrInterrupted = errors.New("prompt: interrupted")
+	// ErrTerminated is returned when the prompt is stopped by SIGTERM.
+	ErrTerminated = errors.New("prompt: terminated")
+	// ErrEOF is returned when the user sends EOF by pressing Ctrl+D on an empty line.
+	ErrEOF = errors.New("prompt: EOF")
+
+	// errExitChecker tells the main loop that ExitChecker asked to stop the prompt.
+	errExitChecker = errors.New("prompt: stopped by exit checker")
+)
+
 // Executor is called when user input something text.
 type Executor func(string)
 
@@ -18,23 +36,50 @@ type Executor func(string)
 // Exit means exit go-prompt (not the overall Go program)
 type ExitChecker func(in string, breakline bool) bool
 
+// PasteHandler is called with the text pasted in bracketed paste mode before inserting it.
+// It returns the text to insert, or false to discard the paste.
+type PasteHandler func(pasted string) (text string, ok bool)
+
 // Completer should return the suggest item from Document.
 type Completer func(Document) []Suggest
 
 // Prompt is core struct of go-prompt.
 type Prompt struct {
-	in                ConsoleParser
-	buf               *Buffer
-	renderer          *Render
-	executor          Executor
-	history           *History
-	completion        *CompletionManager
-	keyBindings       []KeyBind
-	ASCIICodeBindings []ASCIICodeBind
-	keyBindMode       KeyBindMode
-	completionOnDown  bool
-	exitChecker       ExitChecker
-	skipTearDown      bool
+	in                   ConsoleParser
+	buf                  *Buffer
+	renderer             *Render
+	executor             Executor
+	history              *History
+	killRing             *KillRing
+	completion           *CompletionManager
+	keyBindings          []KeyBind
+	ASCIICodeBindings    []ASCIICodeBind
+	keyBindMode          KeyBindMode
+	completionOnDown     bool
+	completeCommonPrefix bool
+	exitChecker          ExitChecker
+	pasteHandler         PasteHandler
+	vi                   viState
+	search               historySearch
+	autoSuggester        AutoSuggester
+	isComplete           func(Document) bool
+	validator            Validator
+	validateWhileTyping  bool
+	refreshInterval      time.Duration
+	skipTearDown         bool
+	historyErrorHandler  func(error)
+	historyErr           error // the error of the HistoryStore to be reported after leaving raw mode
+
+	events      *eventQueue
+	invalidated chan struct{}
+	decoder     inputDecoder
+	stopInputCh chan struct{}
+	stopInputMu sync.Mutex // guards stopInputCh, which request reads from other goroutines.
+	inputWG     sync.WaitGroup
+
+	outMu    sync.Mutex // guards the writer while the prompt isn't reading input
+	printMu  sync.Mutex
+	printBuf []byte // text written by Write after the last newline
 }
 
 // Exec is the struct contains user input context.
@@ -43,125 +88,402 @@ type Exec struct {
 }
 
 // Run starts prompt.
+// It calls os.Exit when the process receives SIGINT, SIGTERM or SIGQUIT.
+// If the HistoryStore fails to load the history, it reports the error to os.Stderr and starts without it.
+// The errors appending entries are reported to os.Stderr as well unless OptionHistoryErrorHandler is set.
+// Use RunContext to handle these cases by yourself.
 func (p *Prompt) Run() {
+	defer p.warnHistoryErrors()()
+	exitOnSignal(p.RunContext(context.Background()))
+}
+
+// RunContext starts prompt and blocks until ExitChecker stops it, the user sends EOF,
+// a termination signal arrives or ctx is canceled. The terminal is restored before it returns.
+// It returns nil when stopped by ExitChecker, ErrEOF, ErrInterrupted, ErrTerminated or ctx.Err(),
+// or an error if the HistoryStore fails to load the history.
+// The errors appending entries are passed to the handler set by OptionHistoryErrorHandler.
+func (p *Prompt) RunContext(ctx context.Context) error {
+	_, err := p.run(ctx, true)
+	return err
+}
+
+// run is the main loop of Prompt. If execute is true, it passes submitted inputs to the executor
+// and keeps running. Otherwise it returns the first submitted input.
+func (p *Prompt) run(ctx context.Context, execute bool) (string, error) {
 	p.skipTearDown = false
 	defer debug.Teardown()
 	debug.Log("start prompt")
+	if err := p.history.load(); err != nil {
+		return "", err
+	}
+	defer p.reportHistoryError()
 	p.setUp()
 	defer p.tearDown()
+	defer func() {
+		// The requests to the finished prompt are discarded, but the printed text isn't.
+		p.events.remove(eventRequest)
+		p.flushPrints()
+	}()
+	defer func() {
+		if p.completion.async != nil {
+			p.completion.stopAsync()
+		}
+	}()
+
+	if p.keyBindMode == ViKeyBind {
+		p.setViMode(ViInsertMode)
+	}
 
 	if p.completion.showAtStart {
 		p.completion.Update(*p.buf.Document())
 	}
 
+	p.outMu.Lock()
 	p.renderer.Render(p.buf, p.completion)
+	p.renderer.askForCPR()
+	p.outMu.Unlock()
+
+	p.startInput()
+	defer func() {
+		p.drainCPR()
+		p.stopInput()
+	}()
+
+	var refresh <-chan time.Time
+	if p.refreshInterval > 0 {
+		ticker := time.NewTicker(p.refreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
 
-	bufCh := make(chan []byte, 128)
-	stopReadBufCh := make(chan struct{})
-	go p.readBuffer(bufCh, stopReadBufCh)
-
-	exitCh := make(chan int)
-	winSizeCh := make(chan *WinSize)
-	stopHandleSignalCh := make(chan struct{})
-	go p.handleSignals(exitCh, winSizeCh, stopHandleSignalCh)
-
+	var escapeTimer <-chan time.Time
 	for {
+		var keys []KeyEvent
 		select {
-		case b := <-bufCh:
-			if shouldExit, e := p.feed(b); shouldExit {
+		case <-ctx.Done():
+			p.renderer.BreakLine(p.buf)
+			return "", ctx.Err()
+		case <-escapeTimer:
+			escapeTimer = nil
+			keys = p.decoder.Flush()
+		case <-refresh:
+			p.renderer.Render(p.buf, p.completion)
+		case <-p.invalidated:
+			p.renderer.Render(p.buf, p.completion)
+		case <-p.events.ready:
+			ev, ok := p.events.pop()
+			if !ok {
+				continue
+			}
+			switch ev.kind {
+			case eventInput:
+				keys = p.decoder.Feed(ev.input)
+				escapeTimer = nil
+				if p.decoder.Pending() {
+					escapeTimer = time.After(p.decoder.Timeout())
+				}
+			case eventWinSize:
+				p.renderer.UpdateWinSize(ev.winSize)
+				p.renderer.Render(p.buf, p.completion)
+			case eventExit:
 				p.renderer.BreakLine(p.buf)
-				stopReadBufCh <- struct{}{}
-				stopHandleSignalCh <- struct{}{}
-				return
-			} else if e != nil {
-				// Stop goroutine to run readBuffer function
-				stopReadBufCh <- struct{}{}
-				stopHandleSignalCh <- struct{}{}
-
-				// Unset raw mode
-				// Reset to Blocking mode because returned EAGAIN when still set non-blocking mode.
-				debug.AssertNoError(p.in.TearDown())
-				p.executor(e.input)
-
-				p.completion.Update(*p.buf.Document())
-
+				return "", ev.err
+			case eventRequest:
+				if e := ev.fn(); e != nil {
+					if input, done := p.handleExec(e, execute); done {
+						return input, nil
+					}
+				}
+				p.renderer.Render(p.buf, p.completion)
+			case eventPrint:
+				p.renderer.printAbove(string(ev.input))
 				p.renderer.Render(p.buf, p.completion)
+			case eventKeys:
+				keys = ev.keys
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		if input, done, err := p.feedKeys(keys, execute); done {
+			return input, err
+		}
+		// The keys have just been rendered, which satisfies the pending invalidation.
+		select {
+		case <-p.invalidated:
+		default:
+		}
+	}
+}
 
-				if p.exitChecker != nil && p.exitChecker(e.input, true) {
-					p.skipTearDown = true
-					return
-				}
-				// Set raw mode
-				debug.AssertNoError(p.in.Setup())
-				go p.readBuffer(bufCh, stopReadBufCh)
-				go p.handleSignals(exitCh, winSizeCh, stopHandleSignalCh)
+// feedKeys handles keys decoded from a single read and renders the result.
+// It returns done=true when the main loop should return input and err.
+func (p *Prompt) feedKeys(keys []KeyEvent, execute bool) (input string, done bool, err error) {
+	// The report is taken out first, so that it isn't left to the keys after Enter.
+	typed := make([]KeyEvent, 0, len(keys))
+	for _, k := range keys {
+		if !p.renderer.handleCPR(k) {
+			typed = append(typed, k)
+		}
+	}
+	for _, k := range typed {
+		e, err := p.feed(k)
+		if err == errExitChecker {
+			p.renderer.BreakLine(p.buf)
+			return "", true, nil
+		} else if err != nil {
+			p.renderer.BreakLine(p.buf)
+			return "", true, err
+		} else if e == nil {
+			if p.search.active {
+				// Hide suggestions for the matched history entry.
+				p.completion.tmp = nil
 			} else {
 				p.completion.Update(*p.buf.Document())
-				p.renderer.Render(p.buf, p.completion)
 			}
-		case w := <-winSizeCh:
-			p.renderer.UpdateWinSize(w)
-			p.renderer.Render(p.buf, p.completion)
-		case code := <-exitCh:
-			p.renderer.BreakLine(p.buf)
-			p.tearDown()
-			os.Exit(code)
-		default:
-			time.Sleep(10 * time.Millisecond)
+			p.updateAutoSuggestion()
+			p.updateValidation()
+			continue
 		}
+		if input, done := p.handleExec(e, execute); done {
+			return input, true, nil
+		}
+	}
+	p.renderer.Render(p.buf, p.completion)
+	if len(typed) > 0 && len(p.completion.GetSuggestions()) > 0 {
+		// Keep the row of the input up to date to place the completion menu.
+		p.renderer.askForCPR()
+	}
+	return "", false, nil
+}
+
+// handleExec passes the submitted input to the executor if execute is true.
+// It returns done=true when the main loop should return input.
+func (p *Prompt) handleExec(e *Exec, execute bool) (input string, done bool) {
+	if !execute {
+		return e.input, true
+	}
+
+	// Stop reading input and handling signals while the executor runs
+	// because it may read from the terminal by itself.
+	p.drainCPR()
+	p.stopInput()
+	p.renderer.cancelCPR()
+	p.flushPrints()
+
+	// Unset raw mode
+	p.outMu.Lock()
+	debug.AssertNoError(p.in.TearDown())
+	p.renderer.setBracketedPaste(false)
+	debug.AssertNoError(p.renderer.out.Flush())
+	p.outMu.Unlock()
+	p.reportHistoryError()
+	p.executor(e.input)
+
+	p.completion.Update(*p.buf.Document())
+	p.updateAutoSuggestion()
+
+	p.outMu.Lock()
+	p.renderer.Render(p.buf, p.completion)
+	p.outMu.Unlock()
+
+	if p.exitChecker != nil && p.exitChecker(e.input, true) {
+		p.skipTearDown = true
+		return "", true
+	}
+	// Set raw mode
+	p.outMu.Lock()
+	debug.AssertNoError(p.in.Setup())
+	p.renderer.setBracketedPaste(true)
+	p.outMu.Unlock()
+	p.startInput()
+	p.outMu.Lock()
+	p.renderer.askForCPR()
+	p.outMu.Unlock()
+	return "", false
+}
+
+// warnHistoryErrors loads the history for Run and Input, which can't return the errors of the HistoryStore.
+// The prompt starts without the stored history if it fails. Unless OptionHistoryErrorHandler is set,
+// the errors appending entries are reported to os.Stderr until the returned function is called.
+func (p *Prompt) warnHistoryErrors() (restore func()) {
+	if err := p.history.load(); err != nil {
+		fmt.Fprintf(os.Stderr, "prompt: failed to load history: %v\n", err)
+		p.history.loaded = true
+	}
+	if p.historyErrorHandler != nil {
+		return func() {}
+	}
+	p.historyErrorHandler = func(err error) {
+		fmt.Fprintf(os.Stderr, "prompt: failed to append history: %v\n", err)
+	}
+	return func() { p.historyErrorHandler = nil }
+}
+
+// reportHistoryError passes the error kept by submit to the handler.
+// It's called after the terminal leaves raw mode, so that the handler can write to it.
+func (p *Prompt) reportHistoryError() {
+	err := p.historyErr
+	if err == nil {
+		return
+	}
+	p.historyErr = nil
+	if p.historyErrorHandler == nil {
+		debug.Log("failed to append history: " + err.Error())
+		return
+	}
+	p.historyErrorHandler(err)
+}
+
+// exitOnSignal exits the process like a default signal handler
+// when err reports that the prompt is stopped by a signal.
+func exitOnSignal(err error) {
+	switch err {
+	case ErrInterrupted:
+		os.Exit(0)
+	case ErrTerminated:
+		os.Exit(1)
 	}
 }
 
-func (p *Prompt) feed(b []byte) (shouldExit bool, exec *Exec) {
-	key := GetKey(b)
+// feed handles a key. It returns a non-nil error when the prompt should stop,
+// and a non-nil Exec when the user submits the input.
+func (p *Prompt) feed(ev KeyEvent) (exec *Exec, err error) {
+	// The error of the validation is displayed until the next key.
+	p.renderer.validationError = nil
+	if p.search.active && p.feedSearch(ev) {
+		return
+	}
+	if p.keyBindMode == ViKeyBind && p.vi.mode == ViInsertMode && isMetaPrefixed(ev) {
+		// Esc followed by a key within escapeTimeout arrives as Alt+key.
+		// Leave insert mode and handle the key in normal mode.
+		p.feedVi(KeyEvent{Key: Escape, ASCIICode: ev.ASCIICode[:1]})
+		ev = NewKeyEvent(ev.ASCIICode[1:])
+	}
+	key, b := ev.key(), ev.ASCIICode
 	p.buf.lastKeyStroke = key
+	p.killRing.nextKey()
+	if p.acceptAutoSuggestion(ev) {
+		return
+	}
 	// completion
 	completing := p.completion.Completing()
-	p.handleCompletionKeyBinding(key, completing)
+	if p.handleCompletionKeyBinding(key, completing) {
+		return
+	}
+
+	if p.keyBindMode == ViKeyBind && p.feedVi(ev) {
+		if p.exitChecker != nil && p.exitChecker(p.buf.Text(), false) {
+			err = errExitChecker
+		}
+		return
+	}
+	if p.isComplete != nil && p.feedMultiline(ev, key, completing) {
+		return
+	}
 
 	switch key {
 	case Enter, ControlJ, ControlM:
-		p.renderer.BreakLine(p.buf)
-
-		exec = &Exec{input: p.buf.Text()}
-		p.buf = NewBuffer()
-		if exec.input != "" {
-			p.history.Add(exec.input)
+		if p.validate(true) != nil {
+			return
 		}
+		exec = p.submit()
 	case ControlC:
-		p.renderer.BreakLine(p.buf)
-		p.buf = NewBuffer()
-		p.history.Clear()
+		p.abort()
 	case Up, ControlP:
 		if !completing { // Don't use p.completion.Completing() because it takes double operation when switch to selected=-1.
 			if newBuf, changed := p.history.Older(p.buf); changed {
-				p.buf = newBuf
+				p.setBuffer(newBuf)
 			}
 		}
 	case Down, ControlN:
 		if !completing { // Don't use p.completion.Completing() because it takes double operation when switch to selected=-1.
 			if newBuf, changed := p.history.Newer(p.buf); changed {
-				p.buf = newBuf
+				p.setBuffer(newBuf)
 			}
 			return
 		}
+	case ControlR:
+		p.startSearch(false)
+	case ControlS:
+		p.startSearch(true)
 	case ControlD:
 		if p.buf.Text() == "" {
-			shouldExit = true
+			err = ErrEOF
 			return
 		}
+	case BracketedPaste:
+		p.paste(string(b))
 	case NotDefined:
 		if p.handleASCIICodeBinding(b) {
 			return
 		}
-		p.buf.InsertText(string(b), false, true)
+		if ev.Rune != 0 && ev.Modifiers == 0 {
+			p.buf.insertTyped(string(b))
+		}
 	}
 
-	shouldExit = p.handleKeyBinding(key)
+	if p.handleKeyBinding(ev) {
+		err = errExitChecker
+	}
 	return
 }
 
-func (p *Prompt) handleCompletionKeyBinding(key Key, completing bool) {
+// submit breaks the line and returns the input as Exec with a new buffer.
+func (p *Prompt) submit() *Exec {
+	p.renderer.BreakLine(p.buf)
+
+	exec := &Exec{input: p.buf.Text()}
+	p.setBuffer(NewBuffer())
+	if exec.input != "" {
+		p.historyErr = p.history.add(exec.input)
+	}
+	if p.keyBindMode == ViKeyBind {
+		p.setViMode(ViInsertMode)
+	}
+	return exec
+}
+
+// abort breaks the line and discards the input.
+func (p *Prompt) abort() {
+	p.renderer.BreakLine(p.buf)
+	p.setBuffer(NewBuffer())
+	p.history.Clear()
+	if p.keyBindMode == ViKeyBind {
+		p.setViMode(ViInsertMode)
+	}
+}
+
+// setBuffer replaces the buffer with b which shares the kill ring of the prompt.
+// The edit group left open in vi insert mode ends with the old buffer.
+func (p *Prompt) setBuffer(b *Buffer) {
+	p.endViInsert()
+	b.killRing = p.killRing
+	p.buf = b
+}
+
+// paste inserts the text pasted in bracketed paste mode as it is,
+// so that line breaks in it don't submit the input.
+func (p *Prompt) paste(text string) {
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	if p.pasteHandler != nil {
+		var ok bool
+		if text, ok = p.pasteHandler(text); !ok {
+			return
+		}
+	}
+	p.buf.InsertText(text, false, true)
+}
+
+// handleCompletionKeyBinding moves the selection of the suggestions, or inserts the selected one.
+// It returns true when the key is consumed by the grid completion menu.
+func (p *Prompt) handleCompletionKeyBinding(key Key, completing bool) bool {
+	if (key == Tab || key == ControlI) && !completing && p.completeCommonPrefix && p.insertCommonPrefix() {
+		return false
+	}
+	if handled, consumed := p.handleGridKeyBinding(key, completing); handled {
+		return consumed
+	}
 	switch key {
 	case Down:
 		if completing || p.completionOnDown {
@@ -177,21 +499,29 @@ func (p *Prompt) handleCompletionKeyBinding(key Key, completing bool) {
 		p.completion.Previous()
 	default:
 		if s, ok := p.completion.GetSelectedSuggestion(); ok {
-			w := p.buf.Document().GetWordBeforeCursorUntilSeparator(p.completion.wordSeparator)
-			if w != "" {
-				p.buf.DeleteBeforeCursor(len([]rune(w)))
-			}
-			p.buf.InsertText(s.Text, false, true)
+			p.insertSuggestion(s)
 		}
 		p.completion.Reset()
 	}
+	return false
 }
 
-func (p *Prompt) handleKeyBinding(key Key) bool {
+// insertSuggestion replaces the range of the input with s.Text as an undoable edit.
+func (p *Prompt) insertSuggestion(s Suggest) {
+	d := p.buf.Document()
+	start, end := s.replacedRange(d, p.completion.wordSeparator)
+	p.buf.beginEditGroup()
+	p.buf.Delete(end - d.cursorPosition)
+	p.buf.DeleteBeforeCursor(d.cursorPosition - start)
+	p.buf.InsertText(s.Text, false, true)
+	p.buf.endEditGroup()
+}
+
+func (p *Prompt) handleKeyBinding(ev KeyEvent) bool {
 	shouldExit := false
 	for i := range commonKeyBindings {
 		kb := commonKeyBindings[i]
-		if kb.Key == key {
+		if kb.match(ev) {
 			kb.Fn(p.buf)
 		}
 	}
@@ -199,7 +529,7 @@ func (p *Prompt) handleKeyBinding(key Key) bool {
 	if p.keyBindMode == EmacsKeyBind {
 		for i := range emacsKeyBindings {
 			kb := emacsKeyBindings[i]
-			if kb.Key == key {
+			if kb.match(ev) {
 				kb.Fn(p.buf)
 			}
 		}
@@ -208,7 +538,7 @@ func (p *Prompt) handleKeyBinding(key Key) bool {
 	// Custom key bindings
 	for i := range p.keyBindings {
 		kb := p.keyBindings[i]
-		if kb.Key == key {
+		if kb.match(ev) {
 			kb.Fn(p.buf)
 		}
 	}
@@ -230,67 +560,121 @@ func (p *Prompt) handleASCIICodeBinding(b []byte) bool {
 }
 
 // Input just returns user input text.
+// It calls os.Exit when the process receives SIGINT, SIGTERM or SIGQUIT.
+// If the HistoryStore fails to load the history, it reports the error to os.Stderr and starts without it.
+// The errors appending entries are reported to os.Stderr as well unless OptionHistoryErrorHandler is set.
+// Use InputContext to handle these cases by yourself.
 func (p *Prompt) Input() string {
-	defer debug.Teardown()
-	debug.Log("start prompt")
-	p.setUp()
-	defer p.tearDown()
+	defer p.warnHistoryErrors()()
+	in, err := p.InputContext(context.Background())
+	exitOnSignal(err)
+	return in
+}
 
-	if p.completion.showAtStart {
-		p.completion.Update(*p.buf.Document())
-	}
+// InputContext returns user input text. It blocks until the user submits the input,
+// sends EOF, a termination signal arrives or ctx is canceled. The terminal is restored before it returns.
+// It returns an empty string with ErrEOF, ErrInterrupted, ErrTerminated or ctx.Err() when the input is not submitted.
+// The errors appending entries to the HistoryStore are passed to the handler set by OptionHistoryErrorHandler.
+func (p *Prompt) InputContext(ctx context.Context) (string, error) {
+	return p.run(ctx, false)
+}
 
-	p.renderer.Render(p.buf, p.completion)
-	bufCh := make(chan []byte, 128)
-	stopReadBufCh := make(chan struct{})
-	go p.readBuffer(bufCh, stopReadBufCh)
+// SelectedSuggestion returns the suggestion selected in the completion menu.
+// It reads the state of the main loop, so call it only from the callbacks of the prompt,
+// e.g. the function given to OptionBottomToolbar.
+func (p *Prompt) SelectedSuggestion() (Suggest, bool) {
+	return p.completion.GetSelectedSuggestion()
+}
 
-	for {
-		select {
-		case b := <-bufCh:
-			if shouldExit, e := p.feed(b); shouldExit {
-				p.renderer.BreakLine(p.buf)
-				stopReadBufCh <- struct{}{}
-				return ""
-			} else if e != nil {
-				// Stop goroutine to run readBuffer function
-				stopReadBufCh <- struct{}{}
-				return e.input
-			} else {
-				p.completion.Update(*p.buf.Document())
-				p.renderer.Render(p.buf, p.completion)
-			}
-		default:
-			time.Sleep(10 * time.Millisecond)
-		}
+// startInput starts goroutines which send input and signals to the main loop.
+func (p *Prompt) startInput() {
+	stop := make(chan struct{})
+	p.stopInputMu.Lock()
+	p.stopInputCh = stop
+	p.stopInputMu.Unlock()
+	p.inputWG.Add(2)
+	go func() {
+		defer p.inputWG.Done()
+		p.readInput(stop)
+	}()
+	go func() {
+		defer p.inputWG.Done()
+		p.handleSignals(stop)
+	}()
+}
+
+// stopInput stops the goroutines started by startInput and waits for them.
+func (p *Prompt) stopInput() {
+	p.stopInputMu.Lock()
+	stop := p.stopInputCh
+	p.stopInputCh = nil
+	p.stopInputMu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	if in, ok := p.in.(BlockingConsoleParser); ok {
+		debug.AssertNoError(in.Interrupt())
+	}
+	p.inputWG.Wait()
+}
+
+// sendEvent sends ev to the main loop unless stop is closed.
+func (p *Prompt) sendEvent(ev event, stop chan struct{}) bool {
+	select {
+	case <-stop:
+		return false
+	default:
 	}
+	p.events.push(ev)
+	return true
 }
 
-func (p *Prompt) readBuffer(bufCh chan []byte, stopCh chan struct{}) {
-	debug.Log("start reading buffer")
+func (p *Prompt) readInput(stop chan struct{}) {
+	debug.Log("start reading input")
+	defer debug.Log("stop reading input")
 	for {
+		b, err := p.in.Read()
 		select {
-		case <-stopCh:
-			debug.Log("stop reading buffer")
+		case <-stop:
 			return
 		default:
-			if b, err := p.in.Read(); err == nil && !(len(b) == 1 && b[0] == 0) {
-				bufCh <- b
-			}
 		}
-		time.Sleep(10 * time.Millisecond)
+
+		switch {
+		case err == io.EOF:
+			p.sendEvent(event{kind: eventExit, err: ErrEOF}, stop)
+			return
+		case err == ErrReadInterrupted:
+			continue
+		case err != nil:
+			// ConsoleParser which is not a BlockingConsoleParser returns an error like EAGAIN
+			// when there is no input, so wait a little before the next try.
+			time.Sleep(10 * time.Millisecond)
+			continue
+		case len(b) == 0 || (len(b) == 1 && b[0] == 0):
+			continue
+		}
+		if !p.sendEvent(event{kind: eventInput, input: b}, stop) {
+			return
+		}
 	}
 }
 
 func (p *Prompt) setUp() {
+	p.outMu.Lock()
+	defer p.outMu.Unlock()
 	debug.AssertNoError(p.in.Setup())
 	p.renderer.Setup()
 	p.renderer.UpdateWinSize(p.in.GetWinSize())
 }
 
 func (p *Prompt) tearDown() {
+	p.outMu.Lock()
+	defer p.outMu.Unlock()
 	if !p.skipTearDown {
 		debug.AssertNoError(p.in.TearDown())
 	}
+	p.renderer.cancelCPR()
 	p.renderer.TearDown()
 }
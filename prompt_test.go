@@ -0,0 +1,182 @@
+package prompt
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockParser struct {
+	input     chan []byte
+	interrupt chan struct{}
+}
+
+func newMockParser(input ...string) *mockParser {
+	p := &mockParser{
+		input:     make(chan []byte, len(input)),
+		interrupt: make(chan struct{}, 1),
+	}
+	for _, in := range input {
+		p.input <- []byte(in)
+	}
+	return p
+}
+
+func (m *mockParser) Setup() error    { return nil }
+func (m *mockParser) TearDown() error { return nil }
+
+func (m *mockParser) GetWinSize() *WinSize {
+	return &WinSize{Row: 24, Col: 80}
+}
+
+func (m *mockParser) Read() ([]byte, error) {
+	select {
+	case b := <-m.input:
+		return b, nil
+	case <-m.interrupt:
+		return nil, ErrReadInterrupted
+	}
+}
+
+func (m *mockParser) Interrupt() error {
+	select {
+	case m.interrupt <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+type mockWriter struct {
+	VT100Writer
+}
+
+func (w *mockWriter) Flush() error {
+	w.buffer = []byte{}
+	return nil
+}
+
+func newMockPrompt(executor Executor, parser ConsoleParser, opts ...Option) *Prompt {
+	opts = append([]Option{OptionParser(parser), OptionWriter(&mockWriter{})}, opts...)
+	return New(executor, func(Document) []Suggest { return nil }, opts...)
+}
+
+func TestPromptInputContext(t *testing.T) {
+	scenarioTable := []struct {
+		name        string
+		input       []string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "submit",
+			input:    []string{"hello", "\n"},
+			expected: "hello",
+		},
+		{
+			name:        "eof",
+			input:       []string{string([]byte{0x4})},
+			expectedErr: ErrEOF,
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newMockPrompt(dummyExecutor, newMockParser(s.input...))
+			actual, err := p.InputContext(context.Background())
+			if err != s.expectedErr {
+				t.Errorf("Should be %v, but got %v", s.expectedErr, err)
+			}
+			if actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPromptRunContextCanceled(t *testing.T) {
+	var executed []string
+	p := newMockPrompt(func(in string) {
+		executed = append(executed, in)
+	}, newMockParser("foo", "\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := p.RunContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Should be %v, but got %v", context.DeadlineExceeded, err)
+	}
+	if len(executed) != 1 || executed[0] != "foo" {
+		t.Errorf("Should be %#v, but got %#v", []string{"foo"}, executed)
+	}
+}
+
+func TestPromptRunContextExitChecker(t *testing.T) {
+	p := newMockPrompt(dummyExecutor, newMockParser("quit", "\n"), OptionSetExitCheckerOnInput(func(in string, breakline bool) bool {
+		return in == "quit" && breakline
+	}))
+	if err := p.RunContext(context.Background()); err != nil {
+		t.Errorf("Should be nil, but got %v", err)
+	}
+}
+
+func TestPromptBracketedPaste(t *testing.T) {
+	scenarioTable := []struct {
+		name     string
+		handler  PasteHandler
+		expected string
+	}{
+		{
+			name:     "insert as it is",
+			expected: "echo foo\nbar",
+		},
+		{
+			name: "sanitize",
+			handler: func(pasted string) (string, bool) {
+				return strings.Replace(pasted, "\n", " ", -1), true
+			},
+			expected: "echo foo bar",
+		},
+		{
+			name: "reject",
+			handler: func(pasted string) (string, bool) {
+				return "", false
+			},
+			expected: "echo ",
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			parser := newMockParser("echo ", "\x1b[200~foo\r\nbar\x1b[201~", "\n")
+			p := newMockPrompt(dummyExecutor, parser, OptionPasteHandler(s.handler))
+			actual, err := p.InputContext(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPromptUndoCompletion(t *testing.T) {
+	p := New(nil, func(Document) []Suggest {
+		return []Suggest{{Text: "hello"}}
+	}, OptionParser(newMockParser()), OptionWriter(&mockWriter{}))
+
+	for _, b := range [][]byte{[]byte("h"), []byte("e"), {0x9}, []byte(" "), {0x1a}} {
+		if _, err := p.feed(NewKeyEvent(b)); err != nil {
+			t.Fatal(err)
+		}
+		p.completion.Update(*p.buf.Document())
+	}
+	// Ctrl+Z reverts the space, then the completion.
+	if expected := "hello"; p.buf.Text() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.buf.Text())
+	}
+	_, _ = p.feed(NewKeyEvent([]byte{0x1a}))
+	if expected := "he"; p.buf.Text() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.buf.Text())
+	}
+}
@@ -2,6 +2,8 @@ package prompt
 
 import (
 	"runtime"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/c-bata/go-prompt/internal/debug"
 	runewidth "github.com/mattn/go-runewidth"
@@ -11,13 +13,29 @@ import (
 type Render struct {
 	out                ConsoleWriter
 	prefix             string
+	continuationPrefix string
 	livePrefixCallback func() (prefix string, useLivePrefix bool)
 	breakLineCallback  func(*Document)
 	title              string
+	modeIndicator      string
+	searchPrefix       string
+	autoSuggestion     string
+	lexer              Lexer
+	validationError    error
+	bottomToolbar      func(Document) []StyledText
+	rightPrefix        func(Document) []StyledText
+	cursorShape        CursorShape
 	row                uint16
 	col                uint16
 
 	previousCursor int
+	// screenRow is the 1-based row of the beginning of the input on the screen,
+	// which is reported by the terminal. 0 means unknown.
+	screenRow  int
+	cprPending bool
+	cprCursor  int
+	// menuAbove is the number of the rows above the input which the completion menu covers.
+	menuAbove int
 
 	// colors,
 	prefixTextColor              Color
@@ -26,10 +44,17 @@ type Render struct {
 	inputBGColor                 Color
 	previewSuggestionTextColor   Color
 	previewSuggestionBGColor     Color
+	autoSuggestionTextColor      Color
+	autoSuggestionBGColor        Color
+	validationErrorTextColor     Color
+	validationErrorBGColor       Color
+	bottomToolbarTextColor       Color
+	bottomToolbarBGColor         Color
 	suggestionTextColor          Color
 	suggestionBGColor            Color
 	selectedSuggestionTextColor  Color
 	selectedSuggestionBGColor    Color
+	suggestionMatchStyle         *Style
 	descriptionTextColor         Color
 	descriptionBGColor           Color
 	selectedDescriptionTextColor Color
@@ -38,21 +63,67 @@ type Render struct {
 	scrollbarBGColor             Color
 }
 
+// bracketedPasteWriter is implemented by ConsoleWriter which can switch bracketed paste mode.
+type bracketedPasteWriter interface {
+	EnableBracketedPaste()
+	DisableBracketedPaste()
+}
+
+// cursorShapeWriter is implemented by ConsoleWriter which can change the cursor shape.
+type cursorShapeWriter interface {
+	SetCursorShape(CursorShape)
+}
+
+// displayAttributeWriter is implemented by ConsoleWriter which can set display attributes.
+type displayAttributeWriter interface {
+	SetDisplayAttributes(fg, bg Color, attrs ...DisplayAttribute)
+}
+
 // Setup to initialize console output.
 func (r *Render) Setup() {
 	if r.title != "" {
 		r.out.SetTitle(r.title)
-		debug.AssertNoError(r.out.Flush())
+	}
+	r.setBracketedPaste(true)
+	debug.AssertNoError(r.out.Flush())
+}
+
+// setBracketedPaste switches bracketed paste mode if the ConsoleWriter supports it.
+func (r *Render) setBracketedPaste(enable bool) {
+	w, ok := r.out.(bracketedPasteWriter)
+	if !ok {
+		return
+	}
+	if enable {
+		w.EnableBracketedPaste()
+	} else {
+		w.DisableBracketedPaste()
+	}
+}
+
+// setCursorShape changes the cursor shape if the ConsoleWriter supports it.
+func (r *Render) setCursorShape(shape CursorShape) {
+	if shape == r.cursorShape {
+		return
+	}
+	if w, ok := r.out.(cursorShapeWriter); ok {
+		w.SetCursorShape(shape)
+		r.cursorShape = shape
 	}
 }
 
 // getCurrentPrefix to get current prefix.
 // If live-prefix is enabled, return live-prefix.
+// The mode indicator of ViKeyBind is put before it.
+// While searching the history, the prompt of the search is returned instead.
 func (r *Render) getCurrentPrefix() string {
+	if r.searchPrefix != "" {
+		return r.searchPrefix
+	}
 	if prefix, ok := r.livePrefixCallback(); ok {
-		return prefix
+		return r.modeIndicator + prefix
 	}
-	return r.prefix
+	return r.modeIndicator + r.prefix
 }
 
 func (r *Render) renderPrefix() {
@@ -61,8 +132,25 @@ func (r *Render) renderPrefix() {
 	r.out.SetColor(DefaultColor, DefaultColor, false)
 }
 
+// getContinuationPrefix to get the prefix of the lines after the first one in multi-line input.
+// If it isn't set, the spaces as wide as the current prefix are returned.
+func (r *Render) getContinuationPrefix() string {
+	if r.continuationPrefix != "" {
+		return r.continuationPrefix
+	}
+	return strings.Repeat(" ", runewidth.StringWidth(r.getCurrentPrefix()))
+}
+
+func (r *Render) renderContinuationPrefix() {
+	r.out.SetColor(r.prefixTextColor, r.prefixBGColor, false)
+	r.out.WriteStr(r.getContinuationPrefix())
+	r.out.SetColor(DefaultColor, DefaultColor, false)
+}
+
 // TearDown to clear title and erasing.
 func (r *Render) TearDown() {
+	r.setBracketedPaste(false)
+	r.setCursorShape(CursorDefault)
 	r.out.ClearTitle()
 	r.out.EraseDown()
 	debug.AssertNoError(r.out.Flush())
@@ -79,6 +167,9 @@ func (r *Render) prepareArea(lines int) {
 
 // UpdateWinSize called when window size is changed.
 func (r *Render) UpdateWinSize(ws *WinSize) {
+	if r.row != ws.Row || r.col != ws.Col {
+		r.forgetScreenRow()
+	}
 	r.row = ws.Row
 	r.col = ws.Col
 }
@@ -90,10 +181,20 @@ func (r *Render) renderWindowTooSmall() {
 	r.out.WriteStr("Your console window is too small...")
 }
 
-func (r *Render) renderCompletion(buf *Buffer, completions *CompletionManager) {
+// renderCompletion renders the completion menu below the cursor, or above it when there is room only above,
+// and returns the height of the menu below the cursor.
+func (r *Render) renderCompletion(buf *Buffer, completions *CompletionManager) int {
 	suggestions := completions.GetSuggestions()
-	if len(completions.GetSuggestions()) == 0 {
-		return
+	highlight := r.suggestionMatchStyle != nil
+	if len(suggestions) == 0 && completions.Loading() {
+		suggestions = []Suggest{loadingSuggestion}
+		highlight = false
+	}
+	if len(suggestions) == 0 {
+		return 0
+	}
+	if completions.layout == CompletionGrid {
+		return r.renderCompletionGrid(buf, completions, suggestions, highlight)
 	}
 	prefix := r.getCurrentPrefix()
 	formatted, width := formatSuggestions(
@@ -108,15 +209,21 @@ func (r *Render) renderCompletion(buf *Buffer, completions *CompletionManager) {
 		windowHeight = int(completions.max)
 	}
 	formatted = formatted[completions.verticalScroll : completions.verticalScroll+windowHeight]
-	r.prepareArea(windowHeight)
 
-	cursor := runewidth.StringWidth(prefix) + runewidth.StringWidth(buf.Document().TextBeforeCursor())
-	x, _ := r.toPos(cursor)
+	cursor := r.inputPosition(buf.Document().TextBeforeCursor())
+	x, y := r.toPos(cursor)
+	above := r.placeMenuAbove(y, windowHeight)
+	if above {
+		r.out.CursorUp(windowHeight)
+		r.menuAbove = maxInt(windowHeight-y, 0)
+	} else {
+		r.prepareArea(windowHeight)
+	}
 	if x+width >= int(r.col) {
 		cursor = r.backward(cursor, x+width-int(r.col))
 	}
 
-	contentHeight := len(completions.tmp)
+	contentHeight := len(suggestions)
 
 	fractionVisible := float64(windowHeight) / float64(contentHeight)
 	fractionAbove := float64(completions.verticalScroll) / float64(contentHeight)
@@ -131,13 +238,19 @@ func (r *Render) renderCompletion(buf *Buffer, completions *CompletionManager) {
 	selected := completions.selected - completions.verticalScroll
 	r.out.SetColor(White, Cyan, false)
 	for i := 0; i < windowHeight; i++ {
-		r.out.CursorDown(1)
+		if i > 0 || !above {
+			r.out.CursorDown(1)
+		}
+		var matches []int
+		if highlight {
+			s := suggestions[completions.verticalScroll+i]
+			matches = formatMatches(s, completions.matchesOf(buf.Document(), s), runewidth.StringWidth(formatted[i].Text))
+		}
 		if i == selected {
-			r.out.SetColor(r.selectedSuggestionTextColor, r.selectedSuggestionBGColor, true)
+			r.renderSuggestionText(formatted[i].Text, matches, r.selectedSuggestionTextColor, r.selectedSuggestionBGColor, true)
 		} else {
-			r.out.SetColor(r.suggestionTextColor, r.suggestionBGColor, false)
+			r.renderSuggestionText(formatted[i].Text, matches, r.suggestionTextColor, r.suggestionBGColor, false)
 		}
-		r.out.WriteStr(formatted[i].Text)
 
 		if i == selected {
 			r.out.SetColor(r.selectedDescriptionTextColor, r.selectedDescriptionBGColor, false)
@@ -162,8 +275,46 @@ func (r *Render) renderCompletion(buf *Buffer, completions *CompletionManager) {
 		r.out.CursorForward(x + width - int(r.col))
 	}
 
-	r.out.CursorUp(windowHeight)
+	height := windowHeight
+	if above {
+		r.out.CursorDown(1)
+		height = 0
+	} else {
+		r.out.CursorUp(windowHeight)
+	}
 	r.out.SetColor(DefaultColor, DefaultColor, false)
+	return height
+}
+
+// renderSuggestionText writes text in the given colors, highlighting the runes at matches by suggestionMatchStyle.
+func (r *Render) renderSuggestionText(text string, matches []int, fg, bg Color, bold bool) {
+	r.out.SetColor(fg, bg, bold)
+	if len(matches) == 0 {
+		r.out.WriteStr(text)
+		return
+	}
+	matched := make(map[int]bool, len(matches))
+	for _, i := range matches {
+		matched[i] = true
+	}
+	rs := []rune(text)
+	for start := 0; start < len(rs); {
+		end := start + 1
+		for end < len(rs) && matched[end] == matched[start] {
+			end++
+		}
+		if matched[start] {
+			style := *r.suggestionMatchStyle
+			if bold {
+				style.Attributes = append([]DisplayAttribute{DisplayBold}, style.Attributes...)
+			}
+			r.setStyle(style, fg, bg)
+		} else if start > 0 {
+			r.out.SetColor(fg, bg, bold)
+		}
+		r.out.WriteStr(string(rs[start:end]))
+		start = end
+	}
 }
 
 // Render renders to the console.
@@ -175,15 +326,24 @@ func (r *Render) Render(buffer *Buffer, completion *CompletionManager) {
 	}
 	defer func() { debug.AssertNoError(r.out.Flush()) }()
 	r.move(r.previousCursor, 0)
+	r.clearMenuAbove()
 
-	line := buffer.Text()
-	prefix := r.getCurrentPrefix()
-	cursor := runewidth.StringWidth(prefix) + runewidth.StringWidth(line)
+	text, tokens, cursorIndex := r.displayedInput(buffer, completion)
 
 	// prepare area
-	_, y := r.toPos(cursor)
+	_, y := r.toPos(r.inputPosition(text))
 
 	h := y + 1 + int(completion.max)
+	if completion.layout == CompletionGrid {
+		// The description of the selected suggestion is displayed below the grid.
+		h++
+	}
+	if r.validationError != nil {
+		h++
+	}
+	if r.bottomToolbar != nil {
+		h++
+	}
 	if h > int(r.row) || completionMargin > int(r.col) {
 		r.renderWindowTooSmall()
 		return
@@ -194,55 +354,276 @@ func (r *Render) Render(buffer *Buffer, completion *CompletionManager) {
 	defer r.out.ShowCursor()
 
 	r.renderPrefix()
-	r.out.SetColor(r.inputTextColor, r.inputBGColor, false)
-	r.out.WriteStr(line)
-	r.out.SetColor(DefaultColor, DefaultColor, false)
-	r.lineWrap(cursor)
+	cursor, lineWidth := r.renderText(text, tokens)
+	if r.validationError != nil {
+		cursor = r.breakInputLine(cursor, lineWidth)
+		cursor, lineWidth = r.renderValidationError(cursor)
+	}
+	if lineWidth > 0 {
+		r.lineWrap(cursor)
+	}
 
 	r.out.EraseDown()
 
-	cursor = r.backward(cursor, runewidth.StringWidth(line)-buffer.DisplayCursorPosition())
+	end := cursor
+	if r.rightPrefix != nil {
+		firstLine := text
+		if i := strings.IndexByte(text, '\n'); i != -1 {
+			firstLine = text[:i]
+		}
+		r.renderRightPrefix(cursor, runewidth.StringWidth(r.getCurrentPrefix())+runewidth.StringWidth(firstLine), buffer.Document())
+	}
+	cursor = r.move(cursor, r.inputPosition(buffer.Document().TextBeforeCursor()))
+
+	menuHeight := r.renderCompletion(buffer, completion)
+	if r.bottomToolbar != nil {
+		r.renderBottomToolbar(cursor, end, menuHeight, buffer.Document())
+	}
+	r.previousCursor = r.move(cursor, r.inputPosition(string([]rune(text)[:cursorIndex])))
+}
 
-	r.renderCompletion(buffer, completion)
+// displayedInput returns the text displayed as the input, its tokens and the cursor position in it.
+// The selected suggestion is previewed in place of the word before the cursor,
+// and the text suggested by AutoSuggester follows the input.
+func (r *Render) displayedInput(buffer *Buffer, completion *CompletionManager) (string, []Token, int) {
+	d := buffer.Document()
 	if suggest, ok := completion.GetSelectedSuggestion(); ok {
-		cursor = r.backward(cursor, runewidth.StringWidth(buffer.Document().GetWordBeforeCursorUntilSeparator(completion.wordSeparator)))
+		start, _ := suggest.replacedRange(d, completion.wordSeparator)
+		text, cursor := suggest.apply(d, completion.wordSeparator)
+		tokens := append(r.lex(&Document{Text: text, cursorPosition: cursor}), Token{
+			Start: start,
+			End:   cursor,
+			Style: Style{TextColor: r.previewSuggestionTextColor, BGColor: r.previewSuggestionBGColor},
+		})
+		return text, tokens, cursor
+	}
 
-		r.out.SetColor(r.previewSuggestionTextColor, r.previewSuggestionBGColor, false)
-		r.out.WriteStr(suggest.Text)
-		r.out.SetColor(DefaultColor, DefaultColor, false)
-		cursor += runewidth.StringWidth(suggest.Text)
+	text, tokens := d.Text, r.lex(d)
+	if e, ok := r.validationError.(*ValidationError); ok && e.End > e.Start {
+		tokens = append(tokens, Token{Start: e.Start, End: e.End, Style: Style{Attributes: []DisplayAttribute{DisplayUnderline}}})
+	}
+	if from, to, ok := buffer.selection(); ok {
+		tokens = append(tokens, Token{Start: from, End: to, Style: Style{Attributes: []DisplayAttribute{DisplayReverse}}})
+	}
+	if s := r.autoSuggestion; s != "" {
+		if i := strings.IndexByte(s, '\n'); i != -1 {
+			s = s[:i]
+		}
+		n := utf8.RuneCountInString(text)
+		tokens = append(tokens, Token{
+			Start: n,
+			End:   n + utf8.RuneCountInString(s),
+			Style: Style{TextColor: r.autoSuggestionTextColor, BGColor: r.autoSuggestionBGColor},
+		})
+		text += s
+	}
+	return text, tokens, d.cursorPosition
+}
+
+func (r *Render) lex(d *Document) []Token {
+	if r.lexer == nil {
+		return nil
+	}
+	return r.lexer.Lex(*d)
+}
+
+// renderBottomToolbar renders the toolbar in the row below both the input ending at end
+// and the completion menu below cursor. The cursor goes back to cursor after it.
+func (r *Render) renderBottomToolbar(cursor, end, menuHeight int, d *Document) {
+	_, cursorY := r.toPos(cursor)
+	_, endY := r.toPos(end)
+	y := maxInt(endY, cursorY+menuHeight) + 1
+	r.prepareArea(y - cursorY)
+	r.move(cursor, y*int(r.col))
+
+	// The last column is left blank so that the toolbar doesn't wrap.
+	width := int(r.col) - 1
+	for _, t := range r.bottomToolbar(*d) {
+		text := runewidth.Truncate(singleLine(t.Text), width, "")
+		r.setStyle(t.Style, r.bottomToolbarTextColor, r.bottomToolbarBGColor)
+		r.out.WriteStr(text)
+		width -= runewidth.StringWidth(text)
+	}
+	r.out.SetColor(r.bottomToolbarTextColor, r.bottomToolbarBGColor, false)
+	r.out.WriteStr(strings.Repeat(" ", width))
+	r.out.SetColor(DefaultColor, DefaultColor, false)
+	r.move(y*int(r.col)+int(r.col)-1, cursor)
+}
+
+// renderRightPrefix renders the right prefix flush-right in the first row unless it overlaps
+// the first line of the input, which is firstLineWidth wide including the prefix.
+// The cursor goes back to cursor after it.
+func (r *Render) renderRightPrefix(cursor, firstLineWidth int, d *Document) {
+	texts := r.rightPrefix(*d)
+	width := 0
+	for i := range texts {
+		texts[i].Text = singleLine(texts[i].Text)
+		width += runewidth.StringWidth(texts[i].Text)
+	}
+	// The last column is left blank so that the right prefix doesn't wrap.
+	// At least a space is needed between the input and it.
+	x := int(r.col) - 1 - width
+	if width == 0 || firstLineWidth >= x {
+		return
+	}
+	r.move(cursor, x)
+	for _, t := range texts {
+		r.setStyle(t.Style, r.prefixTextColor, r.prefixBGColor)
+		r.out.WriteStr(t.Text)
+	}
+	r.out.SetColor(DefaultColor, DefaultColor, false)
+	r.move(x+width, cursor)
+}
+
+// singleLine replaces the line breaks in s with spaces.
+func singleLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s)
+}
+
+// renderValidationError writes the message of the validation error in a line from cursor.
+// It returns the position after the message and its width.
+func (r *Render) renderValidationError(cursor int) (int, int) {
+	msg := singleLine(r.validationError.Error())
+	r.out.SetColor(r.validationErrorTextColor, r.validationErrorBGColor, false)
+	r.out.WriteStr(msg)
+	r.out.SetColor(DefaultColor, DefaultColor, false)
+	w := runewidth.StringWidth(msg)
+	return cursor + w, w
+}
+
+// renderText writes text after the prefix, splitting it into the runs of the same style.
+// Each line after a newline starts with the continuation prefix.
+// It returns the position after text and the width of its last line.
+func (r *Render) renderText(text string, tokens []Token) (cursor, lineWidth int) {
+	line := []rune(text)
+	styles := make([]Style, len(line))
+	for _, t := range tokens {
+		for i := maxInt(t.Start, 0); i < t.End && i < len(line); i++ {
+			if t.Style.TextColor != DefaultColor {
+				styles[i].TextColor = t.Style.TextColor
+			}
+			if t.Style.BGColor != DefaultColor {
+				styles[i].BGColor = t.Style.BGColor
+			}
+			if len(t.Style.Attributes) > 0 {
+				styles[i].Attributes = append(styles[i].Attributes[:len(styles[i].Attributes):len(styles[i].Attributes)], t.Style.Attributes...)
+			}
+		}
+	}
+	cursor = runewidth.StringWidth(r.getCurrentPrefix())
+	lineWidth = cursor
+	for start := 0; start < len(line); {
+		end := start + 1
+		for end < len(line) && line[end] != '\n' && line[start] != '\n' && sameStyle(styles[start], styles[end]) {
+			end++
+		}
+		if line[start] == '\n' {
+			cursor = r.breakInputLine(cursor, lineWidth)
+			r.renderContinuationPrefix()
+			lineWidth = runewidth.StringWidth(r.getContinuationPrefix())
+			cursor += lineWidth
+		} else {
+			r.setStyle(styles[start], r.inputTextColor, r.inputBGColor)
+			s := string(line[start:end])
+			r.out.WriteStr(s)
+			cursor += runewidth.StringWidth(s)
+			lineWidth += runewidth.StringWidth(s)
+		}
+		start = end
+	}
+	r.out.SetColor(DefaultColor, DefaultColor, false)
+	return cursor, lineWidth
+}
+
+// inputPosition returns the position after text written by renderText.
+func (r *Render) inputPosition(text string) int {
+	lines := strings.Split(text, "\n")
+	cursor := runewidth.StringWidth(r.getCurrentPrefix()) + runewidth.StringWidth(lines[0])
+	lineWidth := cursor
+	for _, l := range lines[1:] {
+		cursor = r.nextRow(cursor, lineWidth)
+		lineWidth = runewidth.StringWidth(r.getContinuationPrefix()) + runewidth.StringWidth(l)
+		cursor += lineWidth
+	}
+	return cursor
+}
+
+// nextRow returns the beginning of the row where the line after cursor starts.
+// If the line before it fills the row, the line wrap has already moved the cursor there.
+func (r *Render) nextRow(cursor, lineWidth int) int {
+	col := int(r.col)
+	if lineWidth > 0 && cursor%col == 0 {
+		return cursor
+	}
+	return (cursor/col + 1) * col
+}
 
-		rest := buffer.Document().TextAfterCursor()
-		r.out.WriteStr(rest)
-		cursor += runewidth.StringWidth(rest)
+// breakInputLine moves the cursor to the row where the line after cursor starts.
+func (r *Render) breakInputLine(cursor, lineWidth int) int {
+	next := r.nextRow(cursor, lineWidth)
+	if next == cursor {
 		r.lineWrap(cursor)
+	} else {
+		// Erase the rest of the row which the previous rendering may leave.
+		r.out.SetColor(DefaultColor, DefaultColor, false)
+		r.out.EraseEndOfLine()
+		r.out.WriteRaw([]byte{'\n'})
+	}
+	return next
+}
+
+// setStyle sets the style of the text written next. DefaultColor is replaced by defaultFG and defaultBG.
+func (r *Render) setStyle(s Style, defaultFG, defaultBG Color) {
+	fg, bg := s.TextColor, s.BGColor
+	if fg == DefaultColor {
+		fg = defaultFG
+	}
+	if bg == DefaultColor {
+		bg = defaultBG
+	}
+	if w, ok := r.out.(displayAttributeWriter); ok && len(s.Attributes) > 0 {
+		w.SetDisplayAttributes(fg, bg, append([]DisplayAttribute{DisplayReset}, s.Attributes...)...)
+		return
+	}
+	bold := false
+	for _, a := range s.Attributes {
+		bold = bold || a == DisplayBold
+	}
+	r.out.SetColor(fg, bg, bold)
+}
 
-		cursor = r.backward(cursor, runewidth.StringWidth(rest))
+func sameStyle(a, b Style) bool {
+	if a.TextColor != b.TextColor || a.BGColor != b.BGColor || len(a.Attributes) != len(b.Attributes) {
+		return false
 	}
-	r.previousCursor = cursor
+	for i := range a.Attributes {
+		if a.Attributes[i] != b.Attributes[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // BreakLine to break line.
 func (r *Render) BreakLine(buffer *Buffer) {
 	// Erasing and Render
-	cursor := runewidth.StringWidth(buffer.Document().TextBeforeCursor()) + runewidth.StringWidth(r.getCurrentPrefix())
-	r.clear(cursor)
+	r.clear(r.inputPosition(buffer.Document().TextBeforeCursor()))
 	r.renderPrefix()
-	r.out.SetColor(r.inputTextColor, r.inputBGColor, false)
-	r.out.WriteStr(buffer.Document().Text + "\n")
-	r.out.SetColor(DefaultColor, DefaultColor, false)
+	r.breakInputLine(r.renderText(buffer.Document().Text, r.lex(buffer.Document())))
 	debug.AssertNoError(r.out.Flush())
 	if r.breakLineCallback != nil {
 		r.breakLineCallback(buffer.Document())
 	}
 
 	r.previousCursor = 0
+	r.forgetScreenRow()
 }
 
 // clear erases the screen from a beginning of input
 // even if there is line break which means input length exceeds a window's width.
 func (r *Render) clear(cursor int) {
 	r.move(cursor, 0)
+	r.clearMenuAbove()
 	r.out.EraseDown()
 }
 
@@ -4,6 +4,7 @@ package prompt
 
 import (
 	"reflect"
+	"strings"
 	"syscall"
 	"testing"
 )
@@ -113,3 +114,107 @@ func TestBreakLineCallback(t *testing.T) {
 		t.Errorf("BreakLine callback not called, i should be 3")
 	}
 }
+
+func TestRenderBottomToolbar(t *testing.T) {
+	toolbar := OptionBottomToolbar(func(d Document) []StyledText {
+		return []StyledText{{Text: "mode: "}, {Text: d.Text, Style: Style{TextColor: Red}}}
+	})
+	scenarioTable := []struct {
+		name     string
+		row      uint16
+		expected string
+	}{
+		{
+			name: "below the input",
+			row:  24,
+			expected: "\x1b[?25l\x1b[0;94;49m> \x1b[0;39;49m\x1b[0;39;49mabc\x1b[0;39;49m\x1b[J" +
+				// Move to the next row and write the toolbar as wide as the window.
+				"\x1bD\x1bM\x1b[1B\x1b[5D\x1b[0;30;47mmode: \x1b[0;91;47mabc\x1b[0;30;47m          \x1b[0;39;49m" +
+				// Go back to the cursor.
+				"\x1b[1A\x1b[14D\x1b[?12l\x1b[?25h",
+		},
+		{
+			name:     "window too small",
+			row:      7,
+			expected: "\x1b[H\x1b[2J\x1b[0;31;107mYour console window is too small...",
+		},
+	}
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			w := &recordingWriter{}
+			p := newMockPrompt(nil, newMockParser(), OptionWriter(w), toolbar)
+			p.renderer.UpdateWinSize(&WinSize{Row: s.row, Col: 20})
+			p.buf.InsertText("abc", false, true)
+			p.renderer.Render(p.buf, p.completion)
+			if actual := string(w.flushed); actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRenderBottomToolbarSelectedSuggestion(t *testing.T) {
+	w := &recordingWriter{}
+	var p *Prompt
+	p = New(nil, func(Document) []Suggest {
+		return []Suggest{{Text: "apple", Description: "fruit"}, {Text: "carrot", Description: "vegetable"}}
+	}, OptionParser(newMockParser()), OptionWriter(w), OptionBottomToolbar(func(Document) []StyledText {
+		if s, ok := p.SelectedSuggestion(); ok {
+			return []StyledText{{Text: s.Description}}
+		}
+		return []StyledText{{Text: "none"}}
+	}))
+	p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 40})
+	p.completion.Update(*p.buf.Document())
+	p.completion.Next()
+	p.completion.Next()
+	p.renderer.Render(p.buf, p.completion)
+	out := string(w.flushed)
+	if expected := "\x1b[0;30;47mvegetable"; !strings.Contains(out, expected) {
+		t.Errorf("Should contain %#v, but got %#v", expected, out)
+	}
+	if strings.Contains(out, "none") {
+		t.Errorf("Should not contain %#v, but got %#v", "none", out)
+	}
+}
+
+func TestRenderRightPrefix(t *testing.T) {
+	rightPrefix := OptionRightPrefix(func(d Document) []StyledText {
+		return []StyledText{{Text: "["}, {Text: "main", Style: Style{TextColor: Green}}, {Text: "]"}}
+	})
+	scenarioTable := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name: "flush-right",
+			text: "ab",
+			expected: "\x1b[?25l\x1b[0;94;49m> \x1b[0;39;49m\x1b[0;39;49mab\x1b[0;39;49m\x1b[J" +
+				// Move to the 13th column leaving the last column blank, and go back to the cursor.
+				"\x1b[9C\x1b[0;94;49m[\x1b[0;92;49mmain\x1b[0;94;49m]\x1b[0;39;49m\x1b[15D\x1b[?12l\x1b[?25h",
+		},
+		{
+			name:     "hidden when the input overlaps it",
+			text:     "abcdefghijk",
+			expected: "\x1b[?25l\x1b[0;94;49m> \x1b[0;39;49m\x1b[0;39;49mabcdefghijk\x1b[0;39;49m\x1b[J\x1b[?12l\x1b[?25h",
+		},
+		{
+			name:     "hidden when the input wraps",
+			text:     "abcdefghijklmnopqrstuvwxyz",
+			expected: "\x1b[?25l\x1b[0;94;49m> \x1b[0;39;49m\x1b[0;39;49mabcdefghijklmnopqrstuvwxyz\x1b[0;39;49m\x1b[J\x1b[?12l\x1b[?25h",
+		},
+	}
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			w := &recordingWriter{}
+			p := newMockPrompt(nil, newMockParser(), OptionWriter(w), rightPrefix)
+			p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 20})
+			p.buf.InsertText(s.text, false, true)
+			p.renderer.Render(p.buf, p.completion)
+			if actual := string(w.flushed); actual != s.expected {
+				t.Errorf("Should be %#v, but got %#v", s.expected, actual)
+			}
+		})
+	}
+}
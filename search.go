@@ -0,0 +1,189 @@
+package prompt
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// historySearch is the state of the incremental history search started by Ctrl+R or Ctrl+S.
+type historySearch struct {
+	active    bool
+	forward   bool
+	failed    bool
+	query     string
+	lastQuery string
+	index     int     // The index of the matched history entry.
+	pos       int     // The position in runes of the match in the entry.
+	original  *Buffer // The buffer before starting the search, which is restored by cancel.
+}
+
+// startSearch starts the incremental history search.
+// It searches toward older entries, or newer entries if forward is true.
+func (p *Prompt) startSearch(forward bool) {
+	if p.completion.async != nil {
+		// The suggestions are hidden while searching.
+		p.completion.stopAsync()
+	}
+	p.search = historySearch{
+		active:    true,
+		forward:   forward,
+		lastQuery: p.search.lastQuery,
+		index:     len(p.history.histories),
+		original:  p.buf,
+	}
+	if forward {
+		p.search.index = -1
+	}
+	p.updateSearchPrefix()
+}
+
+// feedSearch handles a key while searching. It returns false if the key finishes
+// the search and should be handled as usual.
+func (p *Prompt) feedSearch(ev KeyEvent) bool {
+	s := &p.search
+	switch key := ev.key(); key {
+	case ControlR, ControlS:
+		s.forward = key == ControlS
+		if s.query == "" {
+			// Search the last query again like readline.
+			s.query = s.lastQuery
+			p.searchHistory(s.index)
+		} else {
+			p.searchNext()
+		}
+	case Backspace, ControlH:
+		if s.query == "" {
+			return true
+		}
+		q := []rune(s.query)
+		s.query = string(q[:len(q)-1])
+		s.index = len(p.history.histories)
+		if s.forward {
+			s.index = -1
+		}
+		p.searchHistory(s.index)
+	case Enter, ControlJ, ControlM:
+		p.endSearch(true)
+	case ControlG, Escape:
+		p.endSearch(false)
+	case NotDefined:
+		if ev.Rune == 0 || ev.Modifiers != 0 {
+			p.endSearch(true)
+			return false
+		}
+		s.query += string(ev.Rune)
+		p.searchHistory(s.index)
+	default:
+		p.endSearch(true)
+		return false
+	}
+	return true
+}
+
+// searchHistory looks for the query from the history entry at start
+// and shows the matched entry with the query highlighted.
+func (p *Prompt) searchHistory(start int) {
+	s := &p.search
+	if n := len(p.history.histories); start >= n {
+		start = n - 1
+	} else if start < 0 {
+		start = 0
+	}
+	s.failed = false
+	if s.query != "" {
+		index, pos, ok := p.history.Search(s.query, start, s.forward)
+		s.failed = !ok
+		if ok {
+			p.showSearchMatch(index, pos)
+		}
+	}
+	p.updateSearchPrefix()
+}
+
+// searchNext looks for the next match beyond the current one, in the same entry first like readline.
+// The search fails at the oldest or the newest entry, leaving the current match displayed.
+func (p *Prompt) searchNext() {
+	s := &p.search
+	n := len(p.history.histories)
+	index, pos, ok := s.index, 0, false
+	if 0 <= s.index && s.index < n {
+		pos, ok = searchEntry(p.history.histories[s.index], s.query, s.pos, s.forward)
+	}
+	if !ok {
+		next := s.index - 1
+		if s.forward {
+			next = s.index + 1
+		}
+		if 0 <= next && next < n {
+			index, pos, ok = p.history.Search(s.query, next, s.forward)
+		}
+	}
+	s.failed = !ok
+	if ok {
+		p.showSearchMatch(index, pos)
+	}
+	p.updateSearchPrefix()
+}
+
+// searchEntry looks for query in entry before the position pos, or after it if forward is true.
+func searchEntry(entry, query string, pos int, forward bool) (int, bool) {
+	rs := []rune(entry)
+	if pos < 0 || pos >= len(rs) {
+		return 0, false
+	}
+	if forward {
+		from := len(string(rs[:pos+1]))
+		j := strings.Index(entry[from:], query)
+		if j == -1 {
+			return 0, false
+		}
+		return pos + 1 + utf8.RuneCountInString(entry[from:from+j]), true
+	}
+	// The match must start before pos.
+	end := minInt(len(string(rs[:pos]))+len(query)-1, len(entry))
+	j := strings.LastIndex(entry[:end], query)
+	if j == -1 {
+		return 0, false
+	}
+	return utf8.RuneCountInString(entry[:j]), true
+}
+
+// showSearchMatch shows the history entry at index with the match at pos highlighted.
+func (p *Prompt) showSearchMatch(index, pos int) {
+	s := &p.search
+	s.index, s.pos = index, pos
+	buf := NewBuffer()
+	buf.setDocument(&Document{Text: p.history.histories[index], cursorPosition: pos})
+	buf.selectionStart = pos + utf8.RuneCountInString(s.query) - 1
+	p.setBuffer(buf)
+}
+
+// endSearch finishes the search. If accept is true, the matched entry is set to the buffer.
+// Otherwise the buffer before the search is restored.
+func (p *Prompt) endSearch(accept bool) {
+	s := &p.search
+	matched := p.buf
+	p.setBuffer(s.original)
+	if accept && matched != s.original {
+		p.buf.saveUndoState(false)
+		p.buf.setDocument(&Document{Text: matched.Text(), cursorPosition: matched.cursorPosition})
+	}
+	if s.query != "" {
+		s.lastQuery = s.query
+	}
+	s.active = false
+	s.original = nil
+	p.renderer.searchPrefix = ""
+}
+
+func (p *Prompt) updateSearchPrefix() {
+	s := &p.search
+	prefix := "(reverse-i-search)'"
+	if s.forward {
+		prefix = "(i-search)'"
+	}
+	if s.failed {
+		prefix = "(failed " + prefix[1:]
+	}
+	p.renderer.searchPrefix = prefix + s.query + "': "
+}
@@ -0,0 +1,148 @@
+package prompt
+
+import (
+	"testing"
+)
+
+func TestHistorySearch(t *testing.T) {
+	p := newMockPrompt(nil, newMockParser(), OptionHistory([]string{"git status", "ls -la", "git commit -m 'ls'", "echo"}))
+	p.buf.InsertText("typed", false, true)
+
+	feed := func(b ...byte) *Exec {
+		e, err := p.feed(NewKeyEvent(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	check := func(text string, cursor int, prefix string) {
+		t.Helper()
+		if p.buf.Text() != text {
+			t.Errorf("Should be %#v, but got %#v", text, p.buf.Text())
+		}
+		if p.buf.cursorPosition != cursor {
+			t.Errorf("Should be %#v, but got %#v", cursor, p.buf.cursorPosition)
+		}
+		if actual := p.renderer.getCurrentPrefix(); actual != prefix {
+			t.Errorf("Should be %#v, but got %#v", prefix, actual)
+		}
+	}
+
+	feed(0x12) // Ctrl+R
+	check("typed", 5, "(reverse-i-search)'': ")
+	feed('l')
+	feed('s')
+	check("git commit -m 'ls'", 15, "(reverse-i-search)'ls': ")
+	if from, to, ok := p.buf.selection(); !ok || from != 15 || to != 17 {
+		t.Errorf("Should highlight the match, but got %d, %d, %#v", from, to, ok)
+	}
+	feed(0x12) // Ctrl+R
+	check("ls -la", 0, "(reverse-i-search)'ls': ")
+	feed(0x12) // Ctrl+R
+	check("ls -la", 0, "(failed reverse-i-search)'ls': ")
+	feed(0x7f) // Backspace
+	check("git commit -m 'ls'", 15, "(reverse-i-search)'l': ")
+
+	// Ctrl+G restores the buffer before the search.
+	feed(0x7)
+	check("typed", 5, "> ")
+
+	// Ctrl+R with an empty query repeats the last search.
+	feed(0x12)
+	feed(0x12)
+	check("git commit -m 'ls'", 15, "(reverse-i-search)'l': ")
+
+	// Enter accepts the match without submitting it.
+	if e := feed(0xd); e != nil {
+		t.Errorf("Should not submit, but got %#v", e)
+	}
+	check("git commit -m 'ls'", 15, "> ")
+	if _, _, ok := p.buf.selection(); ok {
+		t.Error("Should not highlight after the search")
+	}
+
+	// Accepting the match can be undone.
+	feed(0x1a) // Ctrl+Z
+	check("typed", 5, "> ")
+}
+
+func TestHistorySearchForward(t *testing.T) {
+	p := newMockPrompt(nil, newMockParser(), OptionHistory([]string{"git status", "ls -la", "git commit"}))
+	for _, b := range [][]byte{{0x13}, []byte("git"), {0x13}} {
+		for _, k := range new(inputDecoder).Feed(b) {
+			_, _ = p.feed(k)
+		}
+	}
+	if expected := "git commit"; p.buf.Text() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.buf.Text())
+	}
+	if expected := "(i-search)'git': "; p.renderer.getCurrentPrefix() != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.renderer.getCurrentPrefix())
+	}
+
+	// Other keys finish the search and are handled as usual.
+	_, _ = p.feed(NewKeyEvent([]byte{0x5}))
+	if p.search.active {
+		t.Error("Should finish the search")
+	}
+	if p.buf.cursorPosition != len("git commit") {
+		t.Errorf("Should be %#v, but got %#v", len("git commit"), p.buf.cursorPosition)
+	}
+}
+
+func TestHistorySearchBoundary(t *testing.T) {
+	p := newMockPrompt(nil, newMockParser(), OptionHistory([]string{"ls", "git status", "echo ls ls"}))
+	scenarioTable := []struct {
+		keys   []byte
+		text   string
+		cursor int
+		prefix string
+	}{
+		{keys: []byte{0x12, 'l', 's'}, text: "echo ls ls", cursor: 8, prefix: "(reverse-i-search)'ls': "},
+		// The next match in the same entry.
+		{keys: []byte{0x12}, text: "echo ls ls", cursor: 5, prefix: "(reverse-i-search)'ls': "},
+		{keys: []byte{0x12}, text: "ls", cursor: 0, prefix: "(reverse-i-search)'ls': "},
+		// The search fails at the oldest match.
+		{keys: []byte{0x12}, text: "ls", cursor: 0, prefix: "(failed reverse-i-search)'ls': "},
+		{keys: []byte{0x12}, text: "ls", cursor: 0, prefix: "(failed reverse-i-search)'ls': "},
+		{keys: []byte{0x13}, text: "echo ls ls", cursor: 5, prefix: "(i-search)'ls': "},
+		{keys: []byte{0x13}, text: "echo ls ls", cursor: 8, prefix: "(i-search)'ls': "},
+		{keys: []byte{0x13}, text: "echo ls ls", cursor: 8, prefix: "(failed i-search)'ls': "},
+	}
+	for _, s := range scenarioTable {
+		for _, b := range s.keys {
+			if _, err := p.feed(NewKeyEvent([]byte{b})); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if p.buf.Text() != s.text || p.buf.cursorPosition != s.cursor {
+			t.Errorf("Should be %#v, but got %#v", []interface{}{s.text, s.cursor}, []interface{}{p.buf.Text(), p.buf.cursorPosition})
+		}
+		if actual := p.renderer.getCurrentPrefix(); actual != s.prefix {
+			t.Errorf("Should be %#v, but got %#v", s.prefix, actual)
+		}
+	}
+}
+
+func TestSearchEntry(t *testing.T) {
+	scenarioTable := []struct {
+		entry    string
+		pos      int
+		forward  bool
+		expected int
+		ok       bool
+	}{
+		{entry: "日本 ls 日本 ls", pos: 9, expected: 3, ok: true},
+		{entry: "日本 ls 日本 ls", pos: 3},
+		{entry: "日本 ls 日本 ls", pos: 3, forward: true, expected: 9, ok: true},
+		{entry: "日本 ls 日本 ls", pos: 9, forward: true},
+		{entry: "lls", pos: 1, expected: 0},
+		{entry: "lsls", pos: 0, forward: true, expected: 2, ok: true},
+	}
+	for _, s := range scenarioTable {
+		pos, ok := searchEntry(s.entry, "ls", s.pos, s.forward)
+		if pos != s.expected || ok != s.ok {
+			t.Errorf("Should be %#v, but got %#v", []interface{}{s.expected, s.ok}, []interface{}{pos, ok})
+		}
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/c-bata/go-prompt/internal/debug"
 )
 
-func (p *Prompt) handleSignals(exitCh chan int, winSizeCh chan *WinSize, stop chan struct{}) {
+func (p *Prompt) handleSignals(stop chan struct{}) {
 	in := p.in
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(
@@ -20,6 +20,7 @@ func (p *Prompt) handleSignals(exitCh chan int, winSizeCh chan *WinSize, stop ch
 		syscall.SIGQUIT,
 		syscall.SIGWINCH,
 	)
+	defer signal.Stop(sigCh)
 
 	for {
 		select {
@@ -30,19 +31,19 @@ func (p *Prompt) handleSignals(exitCh chan int, winSizeCh chan *WinSize, stop ch
 			switch s {
 			case syscall.SIGINT: // kill -SIGINT XXXX or Ctrl+c
 				debug.Log("Catch SIGINT")
-				exitCh <- 0
+				p.sendEvent(event{kind: eventExit, err: ErrInterrupted}, stop)
 
 			case syscall.SIGTERM: // kill -SIGTERM XXXX
 				debug.Log("Catch SIGTERM")
-				exitCh <- 1
+				p.sendEvent(event{kind: eventExit, err: ErrTerminated}, stop)
 
 			case syscall.SIGQUIT: // kill -SIGQUIT XXXX
 				debug.Log("Catch SIGQUIT")
-				exitCh <- 0
+				p.sendEvent(event{kind: eventExit, err: ErrInterrupted}, stop)
 
 			case syscall.SIGWINCH:
 				debug.Log("Catch SIGWINCH")
-				winSizeCh <- in.GetWinSize()
+				p.sendEvent(event{kind: eventWinSize, winSize: in.GetWinSize()}, stop)
 			}
 		}
 	}
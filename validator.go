@@ -0,0 +1,42 @@
+package prompt
+
+// Validator checks the input before it's submitted. A non-nil error blocks the submission
+// and its message is displayed below the input.
+type Validator func(Document) error
+
+// ValidationError is an error which tells the range of the invalid text.
+// When it blocks the submission, the cursor moves to Start.
+// The text from Start to End is underlined if End is larger than Start.
+type ValidationError struct {
+	Message string
+	// Start and End are positions in runes, and End is exclusive.
+	Start int
+	End   int
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// validate runs the validator and sets the error to be displayed.
+// If moveCursor is true, the cursor moves to the start of the invalid text.
+func (p *Prompt) validate(moveCursor bool) error {
+	if p.validator == nil {
+		return nil
+	}
+	err := p.validator(*p.buf.Document())
+	p.renderer.validationError = err
+	if e, ok := err.(*ValidationError); ok && moveCursor {
+		p.buf.cursorPosition = maxInt(0, minInt(e.Start, len([]rune(p.buf.Text()))))
+		p.buf.preferredColumn = -1
+	}
+	return err
+}
+
+// updateValidation validates the input again after each key if validation while typing is enabled.
+func (p *Prompt) updateValidation() {
+	if p.validateWhileTyping && p.buf.Text() != "" {
+		_ = p.validate(false)
+	}
+}
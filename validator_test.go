@@ -0,0 +1,98 @@
+package prompt
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	validator := func(d Document) error {
+		if i := strings.Index(d.Text, "!"); i != -1 {
+			return &ValidationError{Message: "unexpected '!'", Start: i, End: i + 1}
+		}
+		if d.Text == "" {
+			return errors.New("empty")
+		}
+		return nil
+	}
+	scenarioTable := []struct {
+		name           string
+		input          string
+		expectedExec   bool
+		expectedCursor int
+		expectedError  string
+	}{
+		{
+			name:         "valid input is submitted",
+			input:        "hello",
+			expectedExec: true,
+		},
+		{
+			name:           "cursor moves to the invalid text",
+			input:          "he!lo",
+			expectedCursor: 2,
+			expectedError:  "unexpected '!'",
+		},
+		{
+			name:          "plain error keeps the cursor",
+			input:         "",
+			expectedError: "empty",
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newMockPrompt(nil, newMockParser(), OptionValidator(validator))
+			p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 80})
+			p.buf.InsertText(s.input, false, true)
+			exec, _ := p.feed(NewKeyEvent([]byte{'\r'}))
+			if (exec != nil) != s.expectedExec {
+				t.Errorf("Should be %#v, but got %#v", s.expectedExec, exec != nil)
+			}
+			if s.expectedExec {
+				return
+			}
+			if actual := p.buf.Text(); actual != s.input {
+				t.Errorf("Should be %#v, but got %#v", s.input, actual)
+			}
+			if actual := p.buf.cursorPosition; actual != s.expectedCursor {
+				t.Errorf("Should be %#v, but got %#v", s.expectedCursor, actual)
+			}
+			if p.renderer.validationError == nil || p.renderer.validationError.Error() != s.expectedError {
+				t.Errorf("Should be %#v, but got %#v", s.expectedError, p.renderer.validationError)
+			}
+
+			// The error is displayed until the next key.
+			_, _ = p.feed(NewKeyEvent([]byte{'a'}))
+			if p.renderer.validationError != nil {
+				t.Errorf("Should be %#v, but got %#v", nil, p.renderer.validationError)
+			}
+		})
+	}
+}
+
+func TestValidateWhileTyping(t *testing.T) {
+	validator := func(d Document) error {
+		if i := strings.Index(d.Text, "!"); i != -1 {
+			return &ValidationError{Message: "unexpected '!'", Start: i, End: len([]rune(d.Text))}
+		}
+		return nil
+	}
+	p := newMockPrompt(nil, newMockParser(), OptionValidator(validator), OptionValidateWhileTyping(true))
+	p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 80})
+	keys := []KeyEvent{NewKeyEvent([]byte("a")), NewKeyEvent([]byte("!")), NewKeyEvent([]byte("b"))}
+	if _, done, _ := p.feedKeys(keys, false); done {
+		t.Errorf("Should be %#v, but got %#v", false, done)
+	}
+	expected := &ValidationError{Message: "unexpected '!'", Start: 1, End: 3}
+	if e, ok := p.renderer.validationError.(*ValidationError); !ok || *e != *expected {
+		t.Errorf("Should be %#v, but got %#v", expected, p.renderer.validationError)
+	}
+	_, tokens, _ := p.renderer.displayedInput(p.buf, p.completion)
+	expectedTokens := []Token{{Start: 1, End: 3, Style: Style{Attributes: []DisplayAttribute{DisplayUnderline}}}}
+	if !reflect.DeepEqual(tokens, expectedTokens) {
+		t.Errorf("Should be %#v, but got %#v", expectedTokens, tokens)
+	}
+}
@@ -0,0 +1,853 @@
+package prompt
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	istrings "github.com/c-bata/go-prompt/internal/strings"
+)
+
+/*
+
+========
+PROGRESS
+========
+
+Modes
+-----
+
+* [x] i a I A   Enter insert mode (before/after the cursor, at the first non-blank/end of the line)
+* [x] Esc       Leave insert or visual mode, or cancel a pending command
+* [x] v         Toggle visual mode
+
+Motions
+-------
+
+* [x] h l       Backward/Forward one character (Backspace and Space as well)
+* [x] w b e     Forward to the next word, backward to the start of the word, forward to the end of the word
+* [x] W B E     Same as w b e. Words are separated by spaces like the other key bindings.
+* [x] 0 ^ $     Go to the beginning, the first non-blank character and the end of the line
+* [x] f t F T   Find a character forward/backward in the line
+* [x] ; ,       Repeat the last f t F T in the same/opposite direction
+* [x] j k       Next/Previous command
+
+Operators
+---------
+
+* [x] d c y     Delete, change and yank with a motion or a text object (dd cc yy for the whole line)
+* [x] x X s S   Same as dl dh cl cc
+* [x] D C Y     Same as d$ c$ yy
+* [x] p P       Put the yanked or deleted text after/before the cursor, or whole lines below/above the line
+* [x] r         Replace characters under the cursor
+* [x] ~         Toggle the case of characters under the cursor
+* [x] .         Repeat the last change
+* [x] u Ctrl+r  Undo and redo
+
+Text objects
+------------
+
+* [x] iw aw     A word (aw includes the spaces around it)
+* [x] i" a"     A quoted string (' and ` as well)
+* [x] i( a(     A block in parentheses (ib ) [ ] { } B < > as well)
+
+Counts like 3w, 2dw and d2w are supported.
+
+*/
+
+// ViMode is the state of ViKeyBind mode.
+type ViMode int
+
+const (
+	// ViInsertMode inserts typed characters like the other key bind modes.
+	ViInsertMode ViMode = iota
+	// ViNormalMode handles typed characters as motions and operators.
+	ViNormalMode
+	// ViVisualMode selects text between the position where it starts and the cursor.
+	ViVisualMode
+)
+
+// viState holds the state of ViKeyBind mode.
+type viState struct {
+	mode      ViMode
+	indicator func(ViMode) string
+
+	pending  []KeyEvent // keys of the command being typed in normal or visual mode
+	register string     // text yanked or deleted last
+	linewise bool       // whether register holds a whole line, which is put on its own line
+	lastFind viCommand  // the last f, F, t or T for ; and ,
+
+	// The last change is replayed by '.'. A change which enters insert mode
+	// is recorded until leaving insert mode.
+	lastChange      []KeyEvent
+	lastChangeCount int
+	recording       []KeyEvent
+	recordingCount  int
+	isRecording     bool
+	replaying       bool
+
+	// The edit group of a command is left open while the text is inserted after it.
+	inserting bool
+}
+
+// viCommand is a parsed command of normal or visual mode.
+type viCommand struct {
+	count  int  // 0 if no count is given
+	op     rune // 'd', 'c' or 'y' for an operator, otherwise 0
+	key    rune // a motion, a command or 'i'/'a' of a text object
+	arg    rune // the character argument of f, F, t, T, r and text objects
+	prefix int  // the number of keys of the leading count
+	inner  int  // the number of keys of the count after the operator
+}
+
+// changeKeys returns keys of cmd without the counts, which '.' replays with the total count.
+func (cmd viCommand) changeKeys(keys []KeyEvent) []KeyEvent {
+	ret := append([]KeyEvent{}, keys[cmd.prefix:]...)
+	if cmd.op != 0 && cmd.inner > 0 {
+		ret = append(ret[:1], ret[1+cmd.inner:]...)
+	}
+	return ret
+}
+
+type viParseResult int
+
+const (
+	viIncomplete viParseResult = iota
+	viInvalid
+	viComplete
+)
+
+const (
+	viMotions        = "hlwbeWBE0^$;, "
+	viFindMotions    = "fFtT"
+	viTextObjects    = "wW\"'`()b[]{}B<>"
+	viNormalCommands = "xXsSDCYpPraiIA~v.jku"
+	viVisualCommands = "dxcsyY~v"
+	viChanges        = "xXsSDCpPr~aiIA"
+)
+
+// parseViCommand parses the runes typed in normal or visual mode.
+func parseViCommand(rs []rune, visual bool) (cmd viCommand, result viParseResult) {
+	i := 0
+	readCount := func() int {
+		n := 0
+		for i < len(rs) && '0' <= rs[i] && rs[i] <= '9' && (n > 0 || rs[i] != '0') {
+			n = n*10 + int(rs[i]-'0')
+			i++
+		}
+		return n
+	}
+	readArg := func() viParseResult {
+		if i == len(rs) {
+			return viIncomplete
+		}
+		cmd.arg = rs[i]
+		return viComplete
+	}
+
+	cmd.count = readCount()
+	cmd.prefix = i
+	if i == len(rs) {
+		return cmd, viIncomplete
+	}
+	cmd.key = rs[i]
+	i++
+
+	switch {
+	case visual && (cmd.key == 'i' || cmd.key == 'a'):
+		return cmd, parseViTextObject(&cmd, readArg)
+	case visual && strings.ContainsRune(viVisualCommands, cmd.key):
+		return cmd, viComplete
+	case !visual && strings.ContainsRune("dcy", cmd.key):
+		cmd.op = cmd.key
+		start := i
+		if n := readCount(); n > 0 {
+			cmd.inner = i - start
+			if cmd.count == 0 {
+				cmd.count = 1
+			}
+			cmd.count *= n
+		}
+		if i == len(rs) {
+			return cmd, viIncomplete
+		}
+		cmd.key = rs[i]
+		i++
+		if cmd.key == cmd.op {
+			return cmd, viComplete
+		}
+		if cmd.key == 'i' || cmd.key == 'a' {
+			return cmd, parseViTextObject(&cmd, readArg)
+		}
+	case !visual && cmd.key == 'r':
+		return cmd, readArg()
+	case !visual && strings.ContainsRune(viNormalCommands, cmd.key):
+		return cmd, viComplete
+	}
+
+	if strings.ContainsRune(viFindMotions, cmd.key) {
+		return cmd, readArg()
+	}
+	if strings.ContainsRune(viMotions, cmd.key) {
+		return cmd, viComplete
+	}
+	return cmd, viInvalid
+}
+
+func parseViTextObject(cmd *viCommand, readArg func() viParseResult) viParseResult {
+	if r := readArg(); r != viComplete {
+		return r
+	}
+	if !strings.ContainsRune(viTextObjects, cmd.arg) {
+		return viInvalid
+	}
+	return viComplete
+}
+
+// feedVi handles a key in ViKeyBind mode. It returns true if the key is consumed.
+func (p *Prompt) feedVi(ev KeyEvent) bool {
+	v := &p.vi
+	switch ev.key() {
+	case Enter, ControlJ, ControlM, ControlC:
+		v.pending = nil
+		v.isRecording = false
+		p.endViInsert()
+		return false
+	}
+
+	if v.mode == ViInsertMode {
+		if v.isRecording && !v.replaying {
+			v.recording = append(v.recording, ev)
+		}
+		if ev.key() != Escape {
+			return false
+		}
+		if v.isRecording && !v.replaying {
+			v.lastChange, v.lastChangeCount = v.recording, v.recordingCount
+			v.recording, v.isRecording = nil, false
+		}
+		p.endViInsert()
+		if p.buf.Document().CurrentLineBeforeCursor() != "" {
+			p.buf.CursorLeft(1)
+		}
+		p.setViMode(ViNormalMode)
+		return true
+	}
+
+	switch {
+	case ev.key() == Escape:
+		if len(v.pending) == 0 && v.mode == ViVisualMode {
+			p.setViMode(ViNormalMode)
+		}
+		v.pending = nil
+		return true
+	case ev.key() == Backspace:
+		ev = NewKeyEvent([]byte{'h'})
+	case ev.key() == ControlR && v.mode == ViNormalMode:
+		v.pending = nil
+		p.buf.Redo()
+		p.clampViCursor()
+		return true
+	case ev.Rune == 0 || ev.Modifiers != 0:
+		// Leave the other keys to the common key bindings.
+		v.pending = nil
+		if v.mode == ViVisualMode {
+			p.setViMode(ViNormalMode)
+		}
+		return false
+	}
+
+	v.pending = append(v.pending, ev)
+	rs := make([]rune, len(v.pending))
+	for i := range v.pending {
+		rs[i] = v.pending[i].Rune
+	}
+	cmd, result := parseViCommand(rs, v.mode == ViVisualMode)
+	switch result {
+	case viIncomplete:
+		return true
+	case viInvalid:
+		v.pending = nil
+		return true
+	}
+	keys := v.pending
+	v.pending = nil
+
+	// A command and the text inserted after it are undone at once.
+	p.buf.beginEditGroup()
+	if v.mode == ViVisualMode {
+		p.executeViVisual(cmd)
+	} else {
+		p.executeVi(cmd)
+		if !v.replaying && (cmd.op == 'd' || cmd.op == 'c' || strings.ContainsRune(viChanges, cmd.key)) {
+			if v.mode == ViInsertMode {
+				v.recording = cmd.changeKeys(keys)
+				v.recordingCount, v.isRecording = cmd.count, true
+			} else {
+				v.lastChange, v.lastChangeCount = cmd.changeKeys(keys), cmd.count
+			}
+		}
+	}
+	if v.mode == ViInsertMode {
+		v.inserting = true
+	} else {
+		p.buf.endEditGroup()
+		p.clampViCursor()
+	}
+	return true
+}
+
+// endViInsert closes the edit group left open by the command which entered insert mode.
+func (p *Prompt) endViInsert() {
+	if p.vi.inserting {
+		p.vi.inserting = false
+		p.buf.endEditGroup()
+	}
+}
+
+// isMetaPrefixed returns whether ev is a key typed just after Esc.
+func isMetaPrefixed(ev KeyEvent) bool {
+	b := ev.ASCIICode
+	return ev.Modifiers&ModAlt != 0 && len(b) > 1 && b[0] == 0x1b && b[1] != '[' && b[1] != 'O'
+}
+
+// ViMode returns the current vi mode. It's ViInsertMode unless the key bind mode is ViKeyBind.
+// Like SelectedSuggestion, call it only from the callbacks of the prompt.
+func (p *Prompt) ViMode() ViMode {
+	return p.vi.mode
+}
+
+// setViMode switches the vi mode and updates the cursor shape and the mode indicator.
+func (p *Prompt) setViMode(mode ViMode) {
+	p.vi.mode = mode
+	switch mode {
+	case ViInsertMode:
+		p.renderer.setCursorShape(CursorBar)
+	default:
+		p.renderer.setCursorShape(CursorBlock)
+	}
+	if mode == ViVisualMode {
+		p.buf.selectionStart = p.buf.cursorPosition
+	} else {
+		p.buf.selectionStart = -1
+	}
+	if p.vi.indicator != nil {
+		p.renderer.modeIndicator = p.vi.indicator(mode)
+	}
+}
+
+// clampViCursor keeps the cursor on a character because normal mode can't place it after the end of the line.
+func (p *Prompt) clampViCursor() {
+	d := p.buf.Document()
+	if d.CurrentLineAfterCursor() == "" && d.CurrentLineBeforeCursor() != "" {
+		p.buf.CursorLeft(1)
+	}
+}
+
+// executeVi executes a command of normal mode.
+func (p *Prompt) executeVi(cmd viCommand) {
+	v := &p.vi
+	count := cmd.count
+	if count == 0 {
+		count = 1
+	}
+	rs := []rune(p.buf.Text())
+	pos := p.buf.cursorPosition
+	start, end := viLineBounds(rs, pos)
+
+	if cmd.op != 0 {
+		var from, to int
+		switch {
+		case cmd.key == cmd.op:
+			from, to = start, end
+			if cmd.op == 'd' {
+				// Delete the line break as well.
+				if to < len(rs) {
+					to++
+				} else if from > 0 {
+					from--
+				}
+			}
+		case cmd.arg != 0 && (cmd.key == 'i' || cmd.key == 'a'):
+			var ok bool
+			if from, to, ok = viTextObject(rs, pos, cmd.key, cmd.arg); !ok {
+				return
+			}
+		default:
+			m := cmd
+			if cmd.op == 'c' && (cmd.key == 'w' || cmd.key == 'W') && pos < end && rs[pos] != ' ' {
+				// cw changes until the end of the word like ce.
+				m.key = 'e'
+			}
+			target, inclusive, ok := v.motion(rs, pos, m)
+			if !ok {
+				return
+			}
+			from, to = pos, target
+			if from > to {
+				from, to = to, from
+			}
+			if inclusive {
+				to++
+			}
+			if (m.key == 'w' || m.key == 'W') && to > end {
+				to = end
+			}
+		}
+		p.viOperate(cmd.op, from, to)
+		if cmd.key == cmd.op {
+			v.setLineRegister(rs, start, end)
+		}
+		if cmd.op == 'y' && cmd.key == 'y' {
+			p.buf.setCursorPosition(pos)
+		}
+		return
+	}
+
+	switch cmd.key {
+	case 'x':
+		p.viOperate('d', pos, minInt(end, pos+count))
+	case 'X':
+		p.viOperate('d', maxInt(start, pos-count), pos)
+	case 's':
+		p.viOperate('c', pos, minInt(end, pos+count))
+	case 'S':
+		p.viOperate('c', start, end)
+		v.setLineRegister(rs, start, end)
+	case 'D':
+		p.viOperate('d', pos, end)
+	case 'C':
+		p.viOperate('c', pos, end)
+	case 'Y':
+		p.viOperate('y', start, end)
+		v.setLineRegister(rs, start, end)
+		p.buf.setCursorPosition(pos)
+	case 'p', 'P':
+		if v.register == "" && !v.linewise {
+			return
+		}
+		if v.linewise {
+			// Put the lines below or above the current line and move to the first of them.
+			at, s := start, strings.Repeat(v.register+"\n", count)
+			if cmd.key == 'p' {
+				at, s = end, strings.Repeat("\n"+v.register, count)
+			}
+			viReplace(p.buf, at, at, s)
+			if cmd.key == 'p' {
+				at++
+			}
+			rs = []rune(p.buf.Text())
+			_, lineEnd := viLineBounds(rs, at)
+			p.buf.setCursorPosition(viFirstNonBlank(rs, at, lineEnd))
+			return
+		}
+		if cmd.key == 'p' && pos < end {
+			pos++
+		}
+		s := strings.Repeat(v.register, count)
+		viReplace(p.buf, pos, pos, s)
+		p.buf.setCursorPosition(pos + utf8.RuneCountInString(s) - 1)
+	case 'r':
+		if pos+count > end {
+			return
+		}
+		viReplace(p.buf, pos, pos+count, strings.Repeat(string(cmd.arg), count))
+		p.buf.setCursorPosition(pos + count - 1)
+	case '~':
+		to := minInt(end, pos+count)
+		viReplace(p.buf, pos, to, toggleCase(string(rs[pos:to])))
+		p.buf.setCursorPosition(to)
+	case 'i':
+		p.setViMode(ViInsertMode)
+	case 'a':
+		if pos < end {
+			p.buf.setCursorPosition(pos + 1)
+		}
+		p.setViMode(ViInsertMode)
+	case 'I':
+		p.buf.setCursorPosition(viFirstNonBlank(rs, start, end))
+		p.setViMode(ViInsertMode)
+	case 'A':
+		p.buf.setCursorPosition(end)
+		p.setViMode(ViInsertMode)
+	case 'v':
+		p.setViMode(ViVisualMode)
+	case 'j', 'k':
+		for i := 0; i < count; i++ {
+			newBuf, changed := p.history.Newer(p.buf)
+			if cmd.key == 'k' {
+				newBuf, changed = p.history.Older(p.buf)
+			}
+			if !changed {
+				break
+			}
+			p.setBuffer(newBuf)
+		}
+	case 'u':
+		for i := 0; i < count; i++ {
+			if !p.buf.Undo() {
+				break
+			}
+		}
+	case '.':
+		p.repeatViChange(cmd.count)
+	default:
+		if target, _, ok := v.motion(rs, pos, cmd); ok {
+			p.buf.setCursorPosition(target)
+		}
+	}
+}
+
+// executeViVisual executes a command of visual mode.
+func (p *Prompt) executeViVisual(cmd viCommand) {
+	rs := []rune(p.buf.Text())
+	from, to, _ := p.buf.selection()
+	switch cmd.key {
+	case 'i', 'a':
+		if from, to, ok := viTextObject(rs, p.buf.cursorPosition, cmd.key, cmd.arg); ok && from < to {
+			p.buf.selectionStart = from
+			p.buf.setCursorPosition(to - 1)
+		}
+	case 'd', 'x':
+		p.viOperate('d', from, to)
+		p.setViMode(ViNormalMode)
+	case 'c', 's':
+		p.viOperate('c', from, to)
+	case 'y', 'Y':
+		p.viOperate('y', from, to)
+		p.setViMode(ViNormalMode)
+	case '~':
+		viReplace(p.buf, from, to, toggleCase(string(rs[from:to])))
+		p.setViMode(ViNormalMode)
+	case 'v':
+		p.setViMode(ViNormalMode)
+	default:
+		if target, _, ok := p.vi.motion(rs, p.buf.cursorPosition, cmd); ok {
+			p.buf.setCursorPosition(target)
+		}
+	}
+}
+
+// viOperate applies the operator op to the text in [from, to).
+func (p *Prompt) viOperate(op rune, from, to int) {
+	rs := []rune(p.buf.Text())
+	if to > len(rs) {
+		to = len(rs)
+	}
+	if from > to {
+		return
+	}
+	p.vi.register = string(rs[from:to])
+	p.vi.linewise = false
+	switch op {
+	case 'y':
+		p.buf.setCursorPosition(from)
+	case 'd':
+		viReplace(p.buf, from, to, "")
+	case 'c':
+		viReplace(p.buf, from, to, "")
+		p.setViMode(ViInsertMode)
+	}
+}
+
+// setLineRegister makes the register hold the line in [start, end) of rs for p and P.
+func (v *viState) setLineRegister(rs []rune, start, end int) {
+	v.register = string(rs[start:end])
+	v.linewise = true
+}
+
+// repeatViChange replays the keys of the last change. A count replaces the count of the change.
+func (p *Prompt) repeatViChange(count int) {
+	v := &p.vi
+	if v.lastChange == nil {
+		return
+	}
+	if count == 0 {
+		count = v.lastChangeCount
+	}
+	var keys []KeyEvent
+	if count > 0 {
+		for _, r := range strconv.Itoa(count) {
+			keys = append(keys, NewKeyEvent([]byte{byte(r)}))
+		}
+	}
+	keys = append(keys, v.lastChange...)
+
+	v.replaying = true
+	defer func() { v.replaying = false }()
+	for _, k := range keys {
+		_, _ = p.feed(k)
+	}
+}
+
+// motion returns the cursor position after moving by the motion of cmd from pos.
+// inclusive reports whether an operator applies to the character at the position as well.
+func (v *viState) motion(rs []rune, pos int, cmd viCommand) (target int, inclusive bool, ok bool) {
+	count := cmd.count
+	if count == 0 {
+		count = 1
+	}
+	text := string(rs)
+	start, end := viLineBounds(rs, pos)
+	target = pos
+	switch cmd.key {
+	case 'h':
+		target = maxInt(start, pos-count)
+	case 'l', ' ':
+		target = minInt(end, pos+count)
+	case '0':
+		target = start
+	case '^':
+		target = viFirstNonBlank(rs, start, end)
+	case '$':
+		target, inclusive = maxInt(start, end-1), true
+	case 'w', 'W':
+		for i := 0; i < count; i++ {
+			target = viNextWordStart(text, target)
+		}
+	case 'b', 'B':
+		for i := 0; i < count; i++ {
+			target = viPreviousWordStart(text, target)
+		}
+	case 'e', 'E':
+		for i := 0; i < count; i++ {
+			target = viEndOfWord(text, target)
+		}
+		inclusive = true
+	case 'f', 'F', 't', 'T':
+		v.lastFind = cmd
+		return viFindChar(rs, pos, cmd.key, cmd.arg, count)
+	case ';', ',':
+		if v.lastFind.key == 0 {
+			return pos, false, false
+		}
+		key := v.lastFind.key
+		if cmd.key == ',' {
+			key = map[rune]rune{'f': 'F', 'F': 'f', 't': 'T', 'T': 't'}[key]
+		}
+		return viFindChar(rs, pos, key, v.lastFind.arg, count)
+	default:
+		return pos, false, false
+	}
+	return target, inclusive, true
+}
+
+// viLineBounds returns the range of the line at pos excluding the line break.
+func viLineBounds(rs []rune, pos int) (start, end int) {
+	start, end = pos, pos
+	for start > 0 && rs[start-1] != '\n' {
+		start--
+	}
+	for end < len(rs) && rs[end] != '\n' {
+		end++
+	}
+	return start, end
+}
+
+func viFirstNonBlank(rs []rune, start, end int) int {
+	for i := start; i < end; i++ {
+		if rs[i] != ' ' && rs[i] != '\t' {
+			return i
+		}
+	}
+	return end
+}
+
+// viNextWordStart returns the start of the next word.
+func viNextWordStart(text string, pos int) int {
+	d := &Document{Text: text, cursorPosition: pos}
+	x := d.TextAfterCursor()
+	i := d.FindEndOfCurrentWord()
+	j := istrings.IndexNotByte(x[i:], ' ')
+	if j == -1 {
+		return pos + utf8.RuneCountInString(x)
+	}
+	return pos + utf8.RuneCountInString(x[:i+j])
+}
+
+// viPreviousWordStart returns the start of the current word,
+// or the previous word if pos is already at the start of a word.
+func viPreviousWordStart(text string, pos int) int {
+	d := &Document{Text: text, cursorPosition: pos}
+	x := d.TextBeforeCursor()
+	return utf8.RuneCountInString(x[:d.FindStartOfPreviousWordWithSpace()])
+}
+
+// viEndOfWord returns the last character of the current word,
+// or the next word if pos is already at the end of a word.
+func viEndOfWord(text string, pos int) int {
+	if pos+1 >= utf8.RuneCountInString(text) {
+		return pos
+	}
+	d := &Document{Text: text, cursorPosition: pos + 1}
+	x := d.TextAfterCursor()
+	if istrings.IndexNotByte(x, ' ') == -1 {
+		return pos
+	}
+	return pos + utf8.RuneCountInString(x[:d.FindEndOfCurrentWordWithSpace()])
+}
+
+// viFindChar finds the count-th c in the line. t and T stop before the character.
+func viFindChar(rs []rune, pos int, key, c rune, count int) (target int, inclusive bool, ok bool) {
+	start, end := viLineBounds(rs, pos)
+	i := pos
+	switch key {
+	case 'f', 't':
+		for n := 0; n < count; n++ {
+			i++
+			for i < end && rs[i] != c {
+				i++
+			}
+			if i >= end {
+				return pos, false, false
+			}
+		}
+		if key == 't' {
+			i--
+		}
+		return i, true, true
+	default:
+		for n := 0; n < count; n++ {
+			i--
+			for i >= start && rs[i] != c {
+				i--
+			}
+			if i < start {
+				return pos, false, false
+			}
+		}
+		if key == 'T' {
+			i++
+		}
+		return i, false, true
+	}
+}
+
+// viTextObject returns the range of the text object at pos.
+// kind is 'i' for the inner object or 'a' for the object with its surroundings.
+func viTextObject(rs []rune, pos int, kind, obj rune) (from, to int, ok bool) {
+	start, end := viLineBounds(rs, pos)
+	switch obj {
+	case 'w', 'W':
+		if pos >= end {
+			return 0, 0, false
+		}
+		space := rs[pos] == ' '
+		from, to = pos, pos+1
+		for from > start && (rs[from-1] == ' ') == space {
+			from--
+		}
+		for to < end && (rs[to] == ' ') == space {
+			to++
+		}
+		if kind == 'a' && !space {
+			if to < end && rs[to] == ' ' {
+				for to < end && rs[to] == ' ' {
+					to++
+				}
+			} else {
+				for from > start && rs[from-1] == ' ' {
+					from--
+				}
+			}
+		}
+		return from, to, true
+	case '"', '\'', '`':
+		var quotes []int
+		for i := start; i < end; i++ {
+			if rs[i] == obj && (i == start || rs[i-1] != '\\') {
+				quotes = append(quotes, i)
+			}
+		}
+		for i := 0; i+1 < len(quotes); i += 2 {
+			// Use the quoted string under the cursor, or the next one.
+			if pos <= quotes[i+1] {
+				from, to = quotes[i], quotes[i+1]+1
+				if kind == 'i' {
+					from, to = from+1, to-1
+				}
+				return from, to, true
+			}
+		}
+		return 0, 0, false
+	}
+
+	var open, close rune
+	switch obj {
+	case '(', ')', 'b':
+		open, close = '(', ')'
+	case '[', ']':
+		open, close = '[', ']'
+	case '{', '}', 'B':
+		open, close = '{', '}'
+	case '<', '>':
+		open, close = '<', '>'
+	default:
+		return 0, 0, false
+	}
+	from, to = -1, -1
+	for i, depth := minInt(pos, len(rs)-1), 0; i >= 0 && from == -1; i-- {
+		switch {
+		case rs[i] == close && i != pos:
+			depth++
+		case rs[i] == open && depth == 0:
+			from = i
+		case rs[i] == open:
+			depth--
+		}
+	}
+	if from == -1 {
+		return 0, 0, false
+	}
+	for i, depth := from+1, 0; i < len(rs) && to == -1; i++ {
+		switch {
+		case rs[i] == open:
+			depth++
+		case rs[i] == close && depth == 0:
+			to = i
+		case rs[i] == close:
+			depth--
+		}
+	}
+	if to == -1 {
+		return 0, 0, false
+	}
+	if kind == 'i' {
+		return from + 1, to, true
+	}
+	return from, to + 1, true
+}
+
+// viReplace replaces the text in [from, to) with s and moves the cursor to from.
+func viReplace(buf *Buffer, from, to int, s string) {
+	rs := []rune(buf.Text())
+	buf.saveUndoState(false)
+	buf.setDocument(&Document{
+		Text:           string(rs[:from]) + s + string(rs[to:]),
+		cursorPosition: from,
+	})
+}
+
+func toggleCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
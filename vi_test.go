@@ -0,0 +1,425 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+)
+
+// feedViKeys feeds each character of keys in ViKeyBind mode. "\x1b" is Escape key.
+func feedViKeys(p *Prompt, keys string) {
+	for _, r := range keys {
+		_, _ = p.feed(NewKeyEvent([]byte(string(r))))
+	}
+}
+
+func newViPrompt(text string, cursor int, mode ViMode) *Prompt {
+	p := newMockPrompt(nil, newMockParser(), OptionSwitchKeyBindMode(ViKeyBind))
+	p.buf.InsertText(text, false, false)
+	p.buf.cursorPosition = cursor
+	p.setViMode(mode)
+	return p
+}
+
+func TestViKeyBindings(t *testing.T) {
+	scenarioTable := []struct {
+		name           string
+		text           string
+		cursor         int
+		keys           string
+		expectedText   string
+		expectedCursor int
+		expectedMode   ViMode
+	}{
+		{
+			name:           "escape moves cursor left",
+			text:           "abc",
+			cursor:         3,
+			keys:           "\x1b",
+			expectedText:   "abc",
+			expectedCursor: 2,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "word motions",
+			text:           "foo bar  baz",
+			cursor:         0,
+			keys:           "\x1bw",
+			expectedText:   "foo bar  baz",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "word motion with count",
+			text:           "foo bar  baz",
+			keys:           "\x1b0" + "2w",
+			expectedText:   "foo bar  baz",
+			expectedCursor: 9,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "end of word",
+			text:           "foo bar baz",
+			keys:           "\x1b0ee",
+			expectedText:   "foo bar baz",
+			expectedCursor: 6,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "backward word",
+			text:           "foo bar baz",
+			cursor:         11,
+			keys:           "\x1bbb",
+			expectedText:   "foo bar baz",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "end of line",
+			text:           "foo bar",
+			keys:           "\x1b0$",
+			expectedText:   "foo bar",
+			expectedCursor: 6,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "find character",
+			text:           "a,b,c,d",
+			keys:           "\x1b0f,;",
+			expectedText:   "a,b,c,d",
+			expectedCursor: 3,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete word",
+			text:           "foo bar baz",
+			keys:           "\x1b0dw",
+			expectedText:   "bar baz",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete with counts",
+			text:           "a b c d e f g",
+			keys:           "\x1b0" + "2d2w",
+			expectedText:   "e f g",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete till character",
+			text:           "foo(bar)",
+			keys:           "\x1b0dt(",
+			expectedText:   "(bar)",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "change word",
+			text:           "foo bar",
+			keys:           "\x1b0cwbaz\x1b",
+			expectedText:   "baz bar",
+			expectedCursor: 2,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "change inner quotes",
+			text:           `echo "hello world"`,
+			cursor:         8,
+			keys:           "\x1bci\"bye\x1b",
+			expectedText:   `echo "bye"`,
+			expectedCursor: 8,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete a word",
+			text:           "foo bar baz",
+			cursor:         5,
+			keys:           "\x1bdaw",
+			expectedText:   "foo baz",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete inner word",
+			text:           "foo bar baz",
+			cursor:         5,
+			keys:           "\x1bdiw",
+			expectedText:   "foo  baz",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete a block",
+			text:           "f(a, (b), c) + 1",
+			cursor:         3,
+			keys:           "\x1bda(",
+			expectedText:   "f + 1",
+			expectedCursor: 1,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete inner nested block",
+			text:           "f(a, (b), c)",
+			cursor:         7,
+			keys:           "\x1bdib",
+			expectedText:   "f(a, (), c)",
+			expectedCursor: 6,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "yank and put",
+			text:           "foo bar",
+			keys:           "\x1b0yw$p",
+			expectedText:   "foo barfoo ",
+			expectedCursor: 10,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "yank line and put below",
+			text:           "abc",
+			keys:           "\x1byyp",
+			expectedText:   "abc\nabc",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "yank line and put above",
+			text:           "abc\n  def",
+			cursor:         7,
+			keys:           "\x1bYP",
+			expectedText:   "abc\n  def\n  def",
+			expectedCursor: 6,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete line and put below",
+			text:           "abc\ndef",
+			keys:           "\x1bddp",
+			expectedText:   "def\nabc",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "put line with count",
+			text:           "abc",
+			keys:           "\x1byy2p",
+			expectedText:   "abc\nabc\nabc",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "delete line",
+			text:           "foo bar",
+			keys:           "\x1bdd",
+			expectedText:   "",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "repeat delete",
+			text:           "a b c d",
+			keys:           "\x1b0dw..",
+			expectedText:   "d",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "repeat change",
+			text:           "foo bar",
+			keys:           "\x1b0cwx\x1bw.",
+			expectedText:   "x x",
+			expectedCursor: 2,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "repeat with count",
+			text:           "abcdefg",
+			keys:           "\x1b0x3.",
+			expectedText:   "efg",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "repeat with count after operator",
+			text:           "a b c d e f g h i j",
+			keys:           "\x1b0d2w.",
+			expectedText:   "e f g h i j",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "repeat with count before operator",
+			text:           "a b c d e f g h i j",
+			keys:           "\x1b02dw.",
+			expectedText:   "e f g h i j",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "repeat with new count",
+			text:           "a b c d e f g h i j",
+			keys:           "\x1b0d2w3.",
+			expectedText:   "f g h i j",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "repeat change with count after operator",
+			text:           "aa bb cc dd ee",
+			keys:           "\x1b0c2wx\x1bw.",
+			expectedText:   "x x ee",
+			expectedCursor: 2,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "append at end of line",
+			text:           "foo",
+			keys:           "\x1b0A!",
+			expectedText:   "foo!",
+			expectedCursor: 4,
+			expectedMode:   ViInsertMode,
+		},
+		{
+			name:           "replace and toggle case",
+			text:           "abc",
+			keys:           "\x1b0rx~",
+			expectedText:   "Xbc",
+			expectedCursor: 1,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "visual delete",
+			text:           "foo bar baz",
+			keys:           "\x1b0wvex",
+			expectedText:   "foo  baz",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "visual text object",
+			text:           "say (hello world)",
+			cursor:         8,
+			keys:           "\x1bvi(c!\x1b",
+			expectedText:   "say (!)",
+			expectedCursor: 5,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "undo change",
+			text:           "foo bar",
+			keys:           "\x1b0cwbaz\x1bu",
+			expectedText:   "foo bar",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "undo with count",
+			text:           "abcd",
+			keys:           "\x1b0xxx2u",
+			expectedText:   "bcd",
+			expectedCursor: 0,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "escape cancels pending command",
+			text:           "foo bar",
+			keys:           "\x1b0d\x1bw",
+			expectedText:   "foo bar",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+		{
+			name:           "invalid command",
+			text:           "foo bar",
+			keys:           "\x1b0dzw",
+			expectedText:   "foo bar",
+			expectedCursor: 4,
+			expectedMode:   ViNormalMode,
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newViPrompt(s.text, s.cursor, ViInsertMode)
+			feedViKeys(p, s.keys)
+			if actual := p.buf.Text(); actual != s.expectedText {
+				t.Errorf("Should be %#v, but got %#v", s.expectedText, actual)
+			}
+			if actual := p.buf.cursorPosition; actual != s.expectedCursor {
+				t.Errorf("Should be %#v, but got %#v", s.expectedCursor, actual)
+			}
+			if p.vi.mode != s.expectedMode {
+				t.Errorf("Should be %#v, but got %#v", s.expectedMode, p.vi.mode)
+			}
+		})
+	}
+}
+
+func TestViMetaPrefixedKey(t *testing.T) {
+	p := newViPrompt("foo bar", 7, ViInsertMode)
+	// Esc and b typed quickly arrive as Alt+b.
+	_, _ = p.feed(NewKeyEvent([]byte{0x1b, 'b'}))
+	if p.vi.mode != ViNormalMode {
+		t.Errorf("Should be %#v, but got %#v", ViNormalMode, p.vi.mode)
+	}
+	if p.buf.cursorPosition != 4 {
+		t.Errorf("Should be %#v, but got %#v", 4, p.buf.cursorPosition)
+	}
+}
+
+func TestViModeIndicatorAndCursorShape(t *testing.T) {
+	p := newMockPrompt(nil, newMockParser(), OptionSwitchKeyBindMode(ViKeyBind), OptionViModeIndicator(func(m ViMode) string {
+		if m == ViInsertMode {
+			return "[I] "
+		}
+		return "[N] "
+	}))
+	p.setViMode(ViInsertMode)
+	if p.renderer.getCurrentPrefix() != "[I] > " {
+		t.Errorf("Should be %#v, but got %#v", "[I] > ", p.renderer.getCurrentPrefix())
+	}
+	if p.renderer.cursorShape != CursorBar {
+		t.Errorf("Should be %#v, but got %#v", CursorBar, p.renderer.cursorShape)
+	}
+
+	feedViKeys(p, "\x1b")
+	if p.ViMode() != ViNormalMode {
+		t.Errorf("Should be %#v, but got %#v", ViNormalMode, p.ViMode())
+	}
+	if p.renderer.getCurrentPrefix() != "[N] > " {
+		t.Errorf("Should be %#v, but got %#v", "[N] > ", p.renderer.getCurrentPrefix())
+	}
+	if p.renderer.cursorShape != CursorBlock {
+		t.Errorf("Should be %#v, but got %#v", CursorBlock, p.renderer.cursorShape)
+	}
+}
+
+func TestViEditGroupEndsWithInsertMode(t *testing.T) {
+	scenarioTable := []struct {
+		name string
+		key  []byte
+	}{
+		{name: "enter", key: []byte{0xd}},
+		{name: "up", key: []byte{0x1b, 0x5b, 0x41}},
+		{name: "control c", key: []byte{0x3}},
+	}
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newViPrompt("foo bar", 0, ViInsertMode)
+			p.validator = func(Document) error { return errors.New("invalid") }
+			p.renderer.UpdateWinSize(&WinSize{Row: 24, Col: 80})
+			p.history.Add("older")
+			buf := p.buf
+			feedViKeys(p, "\x1b0cwbaz")
+			if _, err := p.feed(NewKeyEvent(s.key)); err != nil {
+				t.Fatal(err)
+			}
+			if buf.groupDepth != 0 {
+				t.Errorf("Should be %#v, but got %#v", 0, buf.groupDepth)
+			}
+			if p.buf.groupDepth != 0 {
+				t.Errorf("Should be %#v, but got %#v", 0, p.buf.groupDepth)
+			}
+		})
+	}
+}